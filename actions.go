@@ -19,6 +19,12 @@ type SetBlink bool
 type SetInverted bool
 type SetFraktur bool
 type SetFramed bool
+type SetRapidBlink bool
+type SetConceal bool
+type SetStrikethrough bool
+type SetOverline bool
+type SetUnderlineStyle UnderlineStyle
+type SetUnderlineColor Color
 type Linebreak struct{}
 type CarriageReturn struct{}
 type CursorUp int
@@ -32,6 +38,174 @@ type EraseLine EraseMode
 type SaveCursorPosition struct{}
 type RestoreCursorPosition struct{}
 
+// Backspace moves the cursor back one column, unless it is already at the
+// start of the line
+type Backspace struct{}
+type Bell struct{}
+
+// VerticalTab and FormFeed are treated as linebreaks, as most terminals do
+type VerticalTab struct{}
+type FormFeed struct{}
+
+// InsertCharacters inserts n blank cells at the cursor, shifting the rest of
+// the line right
+type InsertCharacters int
+
+// DeleteCharacters deletes n cells at the cursor, shifting the rest of the
+// line left
+type DeleteCharacters int
+
+// EraseCharacters blanks out n cells at the cursor without shifting anything
+type EraseCharacters int
+
+// InsertLines inserts n blank lines at the cursor, shifting the lines below
+// it down
+type InsertLines int
+
+// DeleteLines deletes n lines at the cursor, shifting the lines below it up
+type DeleteLines int
+
+// Tab moves the cursor forward to the n-th next tab stop
+type Tab int
+
+// BackTab moves the cursor back to the n-th previous tab stop
+type BackTab int
+type SetTabStop struct{}
+type ClearTabStop struct{}
+type ClearAllTabStops struct{}
+
+// SetScrollRegion restricts scrolling to the lines between the top and bottom
+// margins. Lines are numbered as in CursorPosition. A Bottom of 0 means the
+// bottom of the screen, and a zero ScrollRegion resets the margins.
+type SetScrollRegion ScrollRegion
+
+// ScrollUp scrolls the contents of the scroll region up n lines
+type ScrollUp int
+
+// ScrollDown scrolls the contents of the scroll region down n lines
+type ScrollDown int
+
+// Index moves the cursor down a line, scrolling the scroll region up if the
+// cursor is at its bottom margin
+type Index struct{}
+
+// ReverseIndex moves the cursor up a line, scrolling the scroll region down
+// if the cursor is at its top margin
+type ReverseIndex struct{}
+
+// EnterAlternateScreen switches to the alternate screen, which full-screen
+// programs use so that they can be exited without disturbing what was
+// previously on the screen
+type EnterAlternateScreen AlternateScreenMode
+
+// ExitAlternateScreen switches back from the alternate screen
+type ExitAlternateScreen AlternateScreenMode
+type SetTitle string
+type SetHyperlink Hyperlink
+
+// OSC is an Operating System Command that has no more specific Action
+type OSC struct {
+	Code int
+	Data []byte
+}
+
+// ControlString is a DCS, SOS, PM or APC control string, e.g. a sixel image or
+// a tmux passthrough sequence. Data is truncated to the parser's maximum
+// control string size.
+type ControlString struct {
+	Kind ControlStringKind
+	Data []byte
+}
+
+// Unknown is an escape sequence that is not supported, such as an SGR with an
+// unrecognized parameter. Raw is the complete sequence, truncated if it is
+// exceptionally long. For an SGR, the actions for any supported parameters are
+// emitted before the Unknown.
+type Unknown struct {
+	Raw []byte
+}
+
+// SetMode enables an ANSI or DEC private mode
+type SetMode Mode
+
+// ResetMode disables an ANSI or DEC private mode
+type ResetMode Mode
+
+// Mode is an ANSI mode (as set by e.g. "\x1b[4h"), or a DEC private mode (as
+// set by e.g. "\x1b[?25h") if the PrivateMode bit is set
+type Mode int
+
+// PrivateMode is set for DEC private modes
+const PrivateMode Mode = 1 << 16
+
+const (
+	// InsertMode (IRM) makes printed text shift the rest of the line right,
+	// rather than overwriting it
+	InsertMode Mode = 4
+	// NewlineMode (LNM) makes a linebreak return to the start of the line
+	NewlineMode Mode = 20
+	// OriginMode (DECOM) makes cursor positions relative to the scroll region
+	OriginMode = PrivateMode | 6
+	// AutoWrapMode (DECAWM) wraps text at the last column, if the width of
+	// the output is fixed
+	AutoWrapMode = PrivateMode | 7
+	// CursorVisibleMode (DECTCEM) shows the cursor
+	CursorVisibleMode = PrivateMode | 25
+	// BracketedPasteMode marks pasted text with escape sequences
+	BracketedPasteMode = PrivateMode | 2004
+)
+
+// Private returns whether m is a DEC private mode
+func (m Mode) Private() bool {
+	return m&PrivateMode != 0
+}
+
+// Number returns the number that identifies m among the ANSI or DEC private
+// modes
+func (m Mode) Number() int {
+	return int(m &^ PrivateMode)
+}
+
+func (m Mode) isAlternateScreen() bool {
+	switch AlternateScreenMode(m.Number()) {
+	case AlternateScreen, AlternateScreenClearOnExit, AlternateScreenSaveCursor:
+		return m.Private()
+	}
+	return false
+}
+
+// AlternateScreenMode is the DEC private mode used to switch to and from the
+// alternate screen
+type AlternateScreenMode int
+
+const (
+	// AlternateScreen switches screens without clearing anything
+	AlternateScreen AlternateScreenMode = 47
+	// AlternateScreenClearOnExit clears the alternate screen when switching
+	// back from it
+	AlternateScreenClearOnExit AlternateScreenMode = 1047
+	// AlternateScreenSaveCursor saves the cursor position and clears the
+	// alternate screen when switching to it, and restores the cursor position
+	// when switching back
+	AlternateScreenSaveCursor AlternateScreenMode = 1049
+)
+
+// ScrollRegion is the range of lines, from Top to Bottom inclusive, that
+// scroll
+type ScrollRegion struct {
+	Top    int
+	Bottom int
+}
+
+// Hyperlink is the target of an OSC 8 hyperlink. A Hyperlink with an empty
+// URI ends the current hyperlink.
+type Hyperlink struct {
+	// ID optionally identifies hyperlinks that are split up (e.g. over
+	// multiple lines), but belong together
+	ID  string `json:"id,omitempty"`
+	URI string `json:"uri"`
+}
+
 type Pos struct {
 	Line int
 	Col  int
@@ -43,4 +217,7 @@ const (
 	EraseToEnd EraseMode = iota
 	EraseToBeginning
 	EraseAll
+	// EraseScrollback erases the lines that have scrolled off the screen. It
+	// only applies to EraseDisplay.
+	EraseScrollback
 )
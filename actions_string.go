@@ -14,12 +14,30 @@ func (a SetItalic) ActionString() string     { return "SetItalic(" + strconv.For
 func (a SetUnderline) ActionString() string {
 	return "SetUnderline(" + strconv.FormatBool(bool(a)) + ")"
 }
-func (a SetBlink) ActionString() string       { return "SetBlink(" + strconv.FormatBool(bool(a)) + ")" }
-func (a SetInverted) ActionString() string    { return "SetInverted(" + strconv.FormatBool(bool(a)) + ")" }
-func (a SetFraktur) ActionString() string     { return "SetFraktur(" + strconv.FormatBool(bool(a)) + ")" }
-func (a SetFramed) ActionString() string      { return "SetFramed(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetBlink) ActionString() string    { return "SetBlink(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetInverted) ActionString() string { return "SetInverted(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetFraktur) ActionString() string  { return "SetFraktur(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetFramed) ActionString() string   { return "SetFramed(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetRapidBlink) ActionString() string {
+	return "SetRapidBlink(" + strconv.FormatBool(bool(a)) + ")"
+}
+func (a SetConceal) ActionString() string { return "SetConceal(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetStrikethrough) ActionString() string {
+	return "SetStrikethrough(" + strconv.FormatBool(bool(a)) + ")"
+}
+func (a SetOverline) ActionString() string { return "SetOverline(" + strconv.FormatBool(bool(a)) + ")" }
+func (a SetUnderlineStyle) ActionString() string {
+	return "SetUnderlineStyle(" + UnderlineStyle(a).String() + ")"
+}
+func (a SetUnderlineColor) ActionString() string {
+	return "SetUnderlineColor(" + Color(a).String() + ")"
+}
 func (a Linebreak) ActionString() string      { return "Linebreak" }
 func (a CarriageReturn) ActionString() string { return "CarriageReturn" }
+func (a Backspace) ActionString() string      { return "Backspace" }
+func (a Bell) ActionString() string           { return "Bell" }
+func (a VerticalTab) ActionString() string    { return "VerticalTab" }
+func (a FormFeed) ActionString() string       { return "FormFeed" }
 func (a CursorUp) ActionString() string       { return "CursorUp(" + strconv.FormatInt(int64(a), 10) + ")" }
 func (a CursorDown) ActionString() string {
 	return "CursorDown(" + strconv.FormatInt(int64(a), 10) + ")"
@@ -38,6 +56,52 @@ func (a EraseDisplay) ActionString() string          { return "EraseDisplay(" +
 func (a EraseLine) ActionString() string             { return "EraseLine(" + EraseMode(a).String() + ")" }
 func (a SaveCursorPosition) ActionString() string    { return "SaveCursorPosition" }
 func (a RestoreCursorPosition) ActionString() string { return "RestoreCursorPosition" }
+func (a InsertCharacters) ActionString() string {
+	return "InsertCharacters(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a DeleteCharacters) ActionString() string {
+	return "DeleteCharacters(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a EraseCharacters) ActionString() string {
+	return "EraseCharacters(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a InsertLines) ActionString() string {
+	return "InsertLines(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a DeleteLines) ActionString() string {
+	return "DeleteLines(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a Tab) ActionString() string              { return "Tab(" + strconv.FormatInt(int64(a), 10) + ")" }
+func (a BackTab) ActionString() string          { return "BackTab(" + strconv.FormatInt(int64(a), 10) + ")" }
+func (a SetTabStop) ActionString() string       { return "SetTabStop" }
+func (a ClearTabStop) ActionString() string     { return "ClearTabStop" }
+func (a ClearAllTabStops) ActionString() string { return "ClearAllTabStops" }
+func (a SetScrollRegion) ActionString() string {
+	return "SetScrollRegion(" + ScrollRegion(a).String() + ")"
+}
+func (a ScrollUp) ActionString() string { return "ScrollUp(" + strconv.FormatInt(int64(a), 10) + ")" }
+func (a ScrollDown) ActionString() string {
+	return "ScrollDown(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a Index) ActionString() string        { return "Index" }
+func (a ReverseIndex) ActionString() string { return "ReverseIndex" }
+func (a SetMode) ActionString() string      { return "SetMode(" + Mode(a).String() + ")" }
+func (a ResetMode) ActionString() string    { return "ResetMode(" + Mode(a).String() + ")" }
+func (a EnterAlternateScreen) ActionString() string {
+	return "EnterAlternateScreen(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a ExitAlternateScreen) ActionString() string {
+	return "ExitAlternateScreen(" + strconv.FormatInt(int64(a), 10) + ")"
+}
+func (a SetTitle) ActionString() string     { return "SetTitle(" + string(a) + ")" }
+func (a SetHyperlink) ActionString() string { return "SetHyperlink(" + Hyperlink(a).String() + ")" }
+func (a OSC) ActionString() string {
+	return "OSC(" + strconv.FormatInt(int64(a.Code), 10) + ";" + string(a.Data) + ")"
+}
+func (a ControlString) ActionString() string {
+	return "ControlString(" + a.Kind.String() + ";" + string(a.Data) + ")"
+}
+func (a Unknown) ActionString() string { return "Unknown(" + strconv.Quote(string(a.Raw)) + ")" }
 
 func (a Print) String() string                 { return a.ActionString() }
 func (a Reset) String() string                 { return a.ActionString() }
@@ -51,8 +115,18 @@ func (a SetBlink) String() string              { return a.ActionString() }
 func (a SetInverted) String() string           { return a.ActionString() }
 func (a SetFraktur) String() string            { return a.ActionString() }
 func (a SetFramed) String() string             { return a.ActionString() }
+func (a SetRapidBlink) String() string         { return a.ActionString() }
+func (a SetConceal) String() string            { return a.ActionString() }
+func (a SetStrikethrough) String() string      { return a.ActionString() }
+func (a SetOverline) String() string           { return a.ActionString() }
+func (a SetUnderlineStyle) String() string     { return a.ActionString() }
+func (a SetUnderlineColor) String() string     { return a.ActionString() }
 func (a Linebreak) String() string             { return a.ActionString() }
 func (a CarriageReturn) String() string        { return a.ActionString() }
+func (a Backspace) String() string             { return a.ActionString() }
+func (a Bell) String() string                  { return a.ActionString() }
+func (a VerticalTab) String() string           { return a.ActionString() }
+func (a FormFeed) String() string              { return a.ActionString() }
 func (a CursorUp) String() string              { return a.ActionString() }
 func (a CursorDown) String() string            { return a.ActionString() }
 func (a CursorForward) String() string         { return a.ActionString() }
@@ -63,16 +137,77 @@ func (a EraseDisplay) String() string          { return a.ActionString() }
 func (a EraseLine) String() string             { return a.ActionString() }
 func (a SaveCursorPosition) String() string    { return a.ActionString() }
 func (a RestoreCursorPosition) String() string { return a.ActionString() }
+func (a InsertCharacters) String() string      { return a.ActionString() }
+func (a DeleteCharacters) String() string      { return a.ActionString() }
+func (a EraseCharacters) String() string       { return a.ActionString() }
+func (a InsertLines) String() string           { return a.ActionString() }
+func (a DeleteLines) String() string           { return a.ActionString() }
+func (a SetScrollRegion) String() string       { return a.ActionString() }
+func (a ScrollUp) String() string              { return a.ActionString() }
+func (a ScrollDown) String() string            { return a.ActionString() }
+func (a Index) String() string                 { return a.ActionString() }
+func (a ReverseIndex) String() string          { return a.ActionString() }
+func (a SetMode) String() string               { return a.ActionString() }
+func (a ResetMode) String() string             { return a.ActionString() }
+func (a EnterAlternateScreen) String() string  { return a.ActionString() }
+func (a ExitAlternateScreen) String() string   { return a.ActionString() }
+func (a SetTitle) String() string              { return a.ActionString() }
+func (a SetHyperlink) String() string          { return a.ActionString() }
+func (a OSC) String() string                   { return a.ActionString() }
+func (a Tab) String() string                   { return a.ActionString() }
+func (a BackTab) String() string               { return a.ActionString() }
+func (a SetTabStop) String() string            { return a.ActionString() }
+func (a ClearTabStop) String() string          { return a.ActionString() }
+func (a ClearAllTabStops) String() string      { return a.ActionString() }
+func (a ControlString) String() string         { return a.ActionString() }
+func (a Unknown) String() string               { return a.ActionString() }
 
 func (p Pos) String() string {
 	return "L" + strconv.FormatInt(int64(p.Line), 10) + "C" + strconv.FormatInt(int64(p.Col), 10)
 }
 
-var eraseModeNames = [4]string{
-	"undefined",
-	"EraseToBeginning",
-	"EraseToEnd",
-	"EraseAll",
+func (m Mode) String() string {
+	if m.Private() {
+		return "?" + strconv.Itoa(m.Number())
+	}
+	return strconv.Itoa(m.Number())
+}
+
+func (r ScrollRegion) String() string {
+	return strconv.FormatInt(int64(r.Top), 10) + "-" + strconv.FormatInt(int64(r.Bottom), 10)
+}
+
+func (h Hyperlink) String() string {
+	if h.ID == "" {
+		return h.URI
+	}
+	return "id=" + h.ID + ";" + h.URI
+}
+
+func (k ControlStringKind) String() string {
+	switch k {
+	case DeviceControlString:
+		return "DCS"
+	case StartOfString:
+		return "SOS"
+	case PrivacyMessage:
+		return "PM"
+	case ApplicationProgramCommand:
+		return "APC"
+	}
+	return "ESC " + string(rune(k))
 }
 
-func (e EraseMode) String() string { return eraseModeNames[e] }
+var eraseModeNames = [...]string{
+	EraseToEnd:       "EraseToEnd",
+	EraseToBeginning: "EraseToBeginning",
+	EraseAll:         "EraseAll",
+	EraseScrollback:  "EraseScrollback",
+}
+
+func (e EraseMode) String() string {
+	if int(e) >= len(eraseModeNames) {
+		return "EraseMode(" + strconv.Itoa(int(e)) + ")"
+	}
+	return eraseModeNames[e]
+}
@@ -59,6 +59,28 @@ func TestAnsi_Integration_Lines(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "extended colours",
+			events: [][]byte{
+				[]byte("\x1b[38;5;208morange\x1b[m "),
+				[]byte("\x1b[48:2::255:136:0malso orange"),
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data:  []byte("orange"),
+						Style: ansi.Style{Foreground: ansi.Indexed(208)},
+					},
+					{
+						Data: ansi.Text(" "),
+					},
+					{
+						Data:  []byte("also orange"),
+						Style: ansi.Style{Background: ansi.RGB(255, 136, 0)},
+					},
+				},
+			},
+		},
 		{
 			description: "control sequences split over multiple events",
 			events: [][]byte{
@@ -202,6 +224,25 @@ func Benchmark_8192_80_5(b *testing.B) {
 	benchmark(b, 8192, 80, 0.05)
 }
 
+// Appending many differently styled chunks to a single line
+func Benchmark_LongLine(b *testing.B) {
+	var event []byte
+	for i := 0; i < 2000; i++ {
+		event = append(event, fmt.Sprintf("\x1b[3%dmabcdefghij", i%8)...)
+	}
+	b.SetBytes(int64(len(event)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		var lines ansi.Lines
+		writer := ansi.NewWriter(&lines)
+		if _, err := writer.Write(event); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 const modes = "mABCDEFGHfsuJK"
 const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789\t\n\r "
 
@@ -1,8 +1,14 @@
 package ansi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
 
-type Color uint8
+// Color is either the terminal's default color, one of the 16 named colors,
+// an entry in the 256 color palette (see Indexed) or a 24-bit color (see RGB).
+type Color uint32
 
 const (
 	DefaultColor Color = iota
@@ -24,6 +30,14 @@ const (
 	BrightWhite
 )
 
+// The upper byte of a Color identifies how the lower bytes are interpreted.
+const (
+	colorKindMask    Color = 0xff << 24
+	colorKindNamed   Color = 0 << 24
+	colorKindIndexed Color = 1 << 24
+	colorKindRGB     Color = 2 << 24
+)
+
 var colourNames = [17]string{
 	"",
 	"black",
@@ -44,12 +58,91 @@ var colourNames = [17]string{
 	"bright-white",
 }
 
+// Indexed returns the color at index i of the 256 color palette, as set by
+// "\x1b[38;5;<i>m" and "\x1b[48;5;<i>m".
+func Indexed(i uint8) Color {
+	return colorKindIndexed | Color(i)
+}
+
+// Index returns the palette index of an indexed color. ok is false if c was
+// not created by Indexed.
+func (c Color) Index() (i uint8, ok bool) {
+	if c&colorKindMask != colorKindIndexed {
+		return 0, false
+	}
+	return uint8(c), true
+}
+
+// RGB returns a 24-bit color, as set by "\x1b[38;2;<r>;<g>;<b>m" and
+// "\x1b[48;2;<r>;<g>;<b>m".
+func RGB(r, g, b uint8) Color {
+	return colorKindRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// RGB returns the components of a 24-bit color. ok is false if c was not
+// created by RGB.
+func (c Color) RGB() (r, g, b uint8, ok bool) {
+	if c&colorKindMask != colorKindRGB {
+		return 0, 0, 0, false
+	}
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+}
+
+const hexDigits = "0123456789abcdef"
+
 func (c Color) String() string {
-	if int(c) >= len(colourNames) {
-		return ""
+	switch c & colorKindMask {
+	case colorKindNamed:
+		if int(c) >= len(colourNames) {
+			return ""
+		}
+		return colourNames[c]
+	case colorKindIndexed:
+		return strconv.Itoa(int(uint8(c)))
+	case colorKindRGB:
+		hex := [7]byte{'#'}
+		for i := 0; i < 3; i++ {
+			component := uint8(c >> (16 - 8*i))
+			hex[1+2*i] = hexDigits[component>>4]
+			hex[2+2*i] = hexDigits[component&0xf]
+		}
+		return string(hex[:])
 	}
-	return colourNames[c]
+	return ""
 }
+
 func (c Color) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.String())
 }
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	color, ok := parseColor(s)
+	if !ok {
+		return errors.New("ansi: invalid color " + strconv.Quote(s))
+	}
+	*c = color
+	return nil
+}
+
+func parseColor(s string) (Color, bool) {
+	for i, name := range colourNames {
+		if s == name {
+			return Color(i), true
+		}
+	}
+	if len(s) == 7 && s[0] == '#' {
+		rgb, err := strconv.ParseUint(s[1:], 16, 24)
+		if err != nil {
+			return 0, false
+		}
+		return RGB(uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)), true
+	}
+	if i, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Indexed(uint8(i)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,67 @@
+package ansi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestColor_JSON(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		color       ansi.Color
+		json        string
+	}{
+		{
+			description: "default",
+			color:       ansi.DefaultColor,
+			json:        `""`,
+		},
+		{
+			description: "named",
+			color:       ansi.BrightMagenta,
+			json:        `"bright-magenta"`,
+		},
+		{
+			description: "indexed",
+			color:       ansi.Indexed(208),
+			json:        `"208"`,
+		},
+		{
+			description: "indexed within the named range",
+			color:       ansi.Indexed(1),
+			json:        `"1"`,
+		},
+		{
+			description: "rgb",
+			color:       ansi.RGB(255, 136, 0),
+			json:        `"#ff8800"`,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			marshalled, err := json.Marshal(tt.color)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(marshalled)).To(Equal(tt.json))
+
+			var color ansi.Color
+			err = json.Unmarshal(marshalled, &color)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(color).To(Equal(tt.color))
+		})
+	}
+}
+
+func TestColor_UnmarshalJSON_Invalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var color ansi.Color
+	err := json.Unmarshal([]byte(`"256"`), &color)
+	g.Expect(err).To(HaveOccurred())
+
+	err = json.Unmarshal([]byte(`"#ff88zz"`), &color)
+	g.Expect(err).To(HaveOccurred())
+}
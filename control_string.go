@@ -0,0 +1,81 @@
+package ansi
+
+// https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-Controls-beginning-with-ESC
+
+const (
+	// Cancel and substitute abort a control string
+	cancel     = '\x18'
+	substitute = '\x1a'
+
+	// The default maximum number of bytes of a control string that are kept.
+	// Any additional bytes are discarded.
+	defaultMaxControlStringSize = 4096
+)
+
+// ControlStringKind identifies the type of a ControlString by the byte that
+// introduces it (following ESC)
+type ControlStringKind byte
+
+const (
+	DeviceControlString       ControlStringKind = 'P'
+	StartOfString             ControlStringKind = 'X'
+	PrivacyMessage            ControlStringKind = '^'
+	ApplicationProgramCommand ControlStringKind = '_'
+)
+
+// parseControlString reads a DCS, SOS, PM or APC, which is terminated by ST
+// ("ESC \")
+func parseControlString(p *Parser, input []byte) stateFn {
+	for {
+		c, ok := p.next(input)
+		if !ok {
+			return parseControlString
+		}
+		switch c {
+		case escapeCode:
+			return parseControlStringEscape
+		case cancel, substitute:
+			p.ignore()
+			return parseBytes
+		default:
+			p.appendControlString(c)
+		}
+	}
+}
+
+func parseControlStringEscape(p *Parser, input []byte) stateFn {
+	c, ok := p.next(input)
+	if !ok {
+		return parseControlStringEscape
+	}
+	switch c {
+	case '\\':
+		p.emit(ControlString{
+			Kind: p.strKind,
+			Data: append([]byte(nil), p.str...),
+		})
+		return parseBytes
+	case escapeCode:
+		// A doubled ESC is part of the data, e.g. in tmux passthrough
+		// sequences ("ESC P tmux; ... ESC \")
+		p.appendControlString(escapeCode)
+		p.appendControlString(escapeCode)
+		return parseControlString
+	default:
+		// Any other escape sequence aborts the control string
+		p.backup()
+		p.ignore()
+		p.beginEscape()
+		return parseEscapeSequence
+	}
+}
+
+// appendControlString buffers a byte of the current control string, up to the
+// maximum size
+func (p *Parser) appendControlString(c byte) {
+	if len(p.str) < p.maxControlStringSize {
+		p.str = append(p.str, c)
+	} else {
+		p.strTruncated = true
+	}
+}
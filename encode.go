@@ -0,0 +1,466 @@
+package ansi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// sgrBuilder builds the parameters of a single SGR sequence
+type sgrBuilder struct {
+	params []byte
+}
+
+func (b *sgrBuilder) add(params ...int) {
+	for _, param := range params {
+		if len(b.params) > 0 {
+			b.params = append(b.params, ';')
+		}
+		b.params = strconv.AppendInt(b.params, int64(param), 10)
+	}
+}
+
+// addSub adds a parameter with ':' separated sub-parameters, e.g. "4:3"
+func (b *sgrBuilder) addSub(param int, subs ...int) {
+	b.add(param)
+	for _, sub := range subs {
+		b.params = append(b.params, ':')
+		b.params = strconv.AppendInt(b.params, int64(sub), 10)
+	}
+}
+
+func (b *sgrBuilder) bytes() []byte {
+	if len(b.params) == 0 {
+		return nil
+	}
+	seq := make([]byte, 0, len(b.params)+3)
+	seq = append(seq, escapeCode, '[')
+	seq = append(seq, b.params...)
+	return append(seq, 'm')
+}
+
+// The SGR parameters that enable each modifier (other than Underline, which
+// depends on the UnderlineStyle)
+var sgrModifierOn = []struct {
+	modifier StyleModifier
+	param    int
+}{
+	{Bold, 1},
+	{Faint, 2},
+	{Italic, 3},
+	{Blink, 5},
+	{RapidBlink, 6},
+	{Inverted, 7},
+	{Conceal, 8},
+	{Strikethrough, 9},
+	{Fraktur, 20},
+	{Framed, 51},
+	{Overline, 53},
+}
+
+// The SGR parameters that disable modifiers. Some parameters disable multiple
+// modifiers at once.
+var sgrModifierOff = []struct {
+	modifiers StyleModifier
+	param     int
+}{
+	{Bold | Faint, 22},
+	{Italic | Fraktur, 23},
+	{Underline, 24},
+	{Blink | RapidBlink, 25},
+	{Inverted, 27},
+	{Conceal, 28},
+	{Strikethrough, 29},
+	{Framed, 54},
+	{Overline, 55},
+}
+
+func (b *sgrBuilder) addColor(c Color, base int, extended int, dflt int) {
+	if r, g, bl, ok := c.RGB(); ok {
+		b.add(extended, extendedColorRGB, int(r), int(g), int(bl))
+		return
+	}
+	if i, ok := c.Index(); ok {
+		b.add(extended, extendedColorIndexed, int(i))
+		return
+	}
+	switch {
+	case c == DefaultColor:
+		b.add(dflt)
+	case c <= White:
+		b.add(base + int(c-Black))
+	case c <= BrightWhite:
+		b.add(base + 60 + int(c-BrightBlack))
+	}
+}
+
+func (b *sgrBuilder) addForeground(c Color) {
+	b.addColor(c, 30, sgrExtendedForeground, 39)
+}
+
+func (b *sgrBuilder) addBackground(c Color) {
+	b.addColor(c, 40, sgrExtendedBackground, 49)
+}
+
+func (b *sgrBuilder) addUnderlineColor(c Color) {
+	// There are no parameters for named underline colors, so use the
+	// equivalent palette entry
+	if c != DefaultColor && c <= BrightWhite {
+		c = Indexed(uint8(c - Black))
+	}
+	b.addColor(c, 0, sgrUnderlineColor, 59)
+}
+
+func (b *sgrBuilder) addUnderline(u UnderlineStyle) {
+	if u == StraightUnderline {
+		b.add(sgrUnderline)
+		return
+	}
+	b.addSub(sgrUnderline, int(u)+1)
+}
+
+// addModifiers adds the parameters to enable the given modifiers
+func (b *sgrBuilder) addModifiers(m StyleModifier, u UnderlineStyle) {
+	for _, on := range sgrModifierOn {
+		if m&on.modifier != 0 {
+			b.add(on.param)
+		}
+	}
+	if m&Underline != 0 {
+		b.addUnderline(u)
+	}
+}
+
+// StyleTransition returns the shortest SGR sequence that changes the style
+// of a terminal from one Style to another, or nil if they are the same.
+func StyleTransition(from, to Style) []byte {
+	if from == to {
+		return nil
+	}
+
+	var reset sgrBuilder
+	reset.add(0)
+	if to.Foreground != DefaultColor {
+		reset.addForeground(to.Foreground)
+	}
+	if to.Background != DefaultColor {
+		reset.addBackground(to.Background)
+	}
+	reset.addModifiers(to.Modifier, to.UnderlineStyle)
+	if to.UnderlineColor != DefaultColor {
+		reset.addUnderlineColor(to.UnderlineColor)
+	}
+
+	var diff sgrBuilder
+	if from.Foreground != to.Foreground {
+		diff.addForeground(to.Foreground)
+	}
+	if from.Background != to.Background {
+		diff.addBackground(to.Background)
+	}
+	enable := to.Modifier &^ from.Modifier
+	for _, off := range sgrModifierOff {
+		if from.Modifier&off.modifiers&^to.Modifier == 0 {
+			continue
+		}
+		diff.add(off.param)
+		// Re-enable any modifiers that were disabled as a side effect
+		enable |= to.Modifier & off.modifiers
+	}
+	if to.Modifier&Underline != 0 && from.UnderlineStyle != to.UnderlineStyle {
+		enable |= Underline
+	}
+	diff.addModifiers(enable, to.UnderlineStyle)
+	if from.UnderlineColor != to.UnderlineColor {
+		diff.addUnderlineColor(to.UnderlineColor)
+	}
+
+	if len(reset.params) < len(diff.params) {
+		return reset.bytes()
+	}
+	return diff.bytes()
+}
+
+// WriteANSI writes the lines as a stream of text and SGR sequences, separated
+// by "\n". Only the minimal changes in style between consecutive chunks are
+// written, and the style is reset at the end. Hyperlinks are written as OSC 8
+// sequences.
+func (l Lines) WriteANSI(w io.Writer) error {
+	var (
+		buf   bytes.Buffer
+		style Style
+		link  *Hyperlink
+	)
+	for i, line := range l {
+		buf.Reset()
+		if i > 0 {
+			// Reset the background before the linebreak, since many
+			// terminals fill new lines with the current background color
+			if style.Background != DefaultColor {
+				to := style
+				to.Background = DefaultColor
+				buf.Write(StyleTransition(style, to))
+				style = to
+			}
+			buf.WriteByte('\n')
+		}
+		for _, chunk := range line {
+			if !sameLink(link, chunk.Link) {
+				buf.Write(hyperlinkTransition(chunk.Link))
+				link = chunk.Link
+			}
+			buf.Write(StyleTransition(style, chunk.Style))
+			style = chunk.Style
+			buf.Write(chunk.Data)
+		}
+		if i == len(l)-1 {
+			if link != nil {
+				buf.Write(hyperlinkTransition(nil))
+			}
+			buf.Write(StyleTransition(style, Style{}))
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hyperlinkTransition returns the OSC 8 sequence that starts the link, or ends
+// the current link if it is nil
+func hyperlinkTransition(link *Hyperlink) []byte {
+	action := SetHyperlink{}
+	if link != nil {
+		action = SetHyperlink(*link)
+	}
+	seq, _ := AppendAction(nil, action)
+	return seq
+}
+
+// Encoder writes Actions as the canonical escape sequences that Parser would
+// parse them from.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes a single action. Print actions are written as-is, so they
+// should not contain control characters if the output is to be parsed again.
+func (e *Encoder) Encode(action Action) error {
+	var err error
+	e.buf, err = AppendAction(e.buf[:0], action)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// The parameter that enables or disables the modifier set by an action
+func sgrModifierParam(enabled bool, on, off int) int {
+	if enabled {
+		return on
+	}
+	return off
+}
+
+// AppendAction appends the canonical encoding of an action to dst.
+//
+// Parsing the encoding gives back the same action, except where there is no
+// sequence for exactly that action:
+//   - SetBold(false) and SetFaint(false) are both encoded as SGR 22, which is
+//     parsed as both actions. The same goes for SetItalic(false) and
+//     SetFraktur(false) (SGR 23), and SetBlink(false) and
+//     SetRapidBlink(false) (SGR 25).
+//   - SetUnderlineStyle(StraightUnderline) is encoded as SGR 4, which is
+//     parsed as SetUnderline(true).
+//   - A named SetUnderlineColor is encoded as the equivalent Indexed color.
+func AppendAction(dst []byte, action Action) ([]byte, error) {
+	var sgr sgrBuilder
+	switch v := action.(type) {
+	case Print:
+		return append(dst, v...), nil
+	case Linebreak:
+		return append(dst, '\n'), nil
+	case CarriageReturn:
+		return append(dst, '\r'), nil
+	case Backspace:
+		return append(dst, '\b'), nil
+	case Bell:
+		return append(dst, '\a'), nil
+	case VerticalTab:
+		return append(dst, '\v'), nil
+	case FormFeed:
+		return append(dst, '\f'), nil
+	case Reset:
+		sgr.add(0)
+	case SetForeground:
+		sgr.addForeground(Color(v))
+	case SetBackground:
+		sgr.addBackground(Color(v))
+	case SetUnderlineColor:
+		sgr.addUnderlineColor(Color(v))
+	case SetUnderlineStyle:
+		sgr.addUnderline(UnderlineStyle(v))
+	case SetBold:
+		sgr.add(sgrModifierParam(bool(v), 1, 22))
+	case SetFaint:
+		sgr.add(sgrModifierParam(bool(v), 2, 22))
+	case SetItalic:
+		sgr.add(sgrModifierParam(bool(v), 3, 23))
+	case SetUnderline:
+		sgr.add(sgrModifierParam(bool(v), 4, 24))
+	case SetBlink:
+		sgr.add(sgrModifierParam(bool(v), 5, 25))
+	case SetRapidBlink:
+		sgr.add(sgrModifierParam(bool(v), 6, 25))
+	case SetInverted:
+		sgr.add(sgrModifierParam(bool(v), 7, 27))
+	case SetConceal:
+		sgr.add(sgrModifierParam(bool(v), 8, 28))
+	case SetStrikethrough:
+		sgr.add(sgrModifierParam(bool(v), 9, 29))
+	case SetFraktur:
+		sgr.add(sgrModifierParam(bool(v), 20, 23))
+	case SetFramed:
+		sgr.add(sgrModifierParam(bool(v), 51, 54))
+	case SetOverline:
+		sgr.add(sgrModifierParam(bool(v), 53, 55))
+	case CursorUp:
+		return appendControlSequence(dst, 'A', int(v))
+	case CursorDown:
+		return appendControlSequence(dst, 'B', int(v))
+	case CursorForward:
+		return appendControlSequence(dst, 'C', int(v))
+	case CursorBack:
+		return appendControlSequence(dst, 'D', int(v))
+	case CursorColumn:
+		return appendControlSequence(dst, 'G', int(v))
+	case CursorPosition:
+		return appendControlSequence(dst, 'H', v.Line, v.Col)
+	case EraseDisplay:
+		return appendControlSequence(dst, 'J', int(v))
+	case EraseLine:
+		return appendControlSequence(dst, 'K', int(v))
+	case SetScrollRegion:
+		return appendControlSequence(dst, 'r', v.Top, v.Bottom)
+	case ScrollUp:
+		return appendControlSequence(dst, 'S', int(v))
+	case ScrollDown:
+		return appendControlSequence(dst, 'T', int(v))
+	case Index:
+		return append(dst, escapeCode, 'D'), nil
+	case ReverseIndex:
+		return append(dst, escapeCode, 'M'), nil
+	case SetMode:
+		return appendMode(dst, 'h', Mode(v))
+	case ResetMode:
+		return appendMode(dst, 'l', Mode(v))
+	case EnterAlternateScreen:
+		return appendPrivateControlSequence(dst, 'h', int(v))
+	case ExitAlternateScreen:
+		return appendPrivateControlSequence(dst, 'l', int(v))
+	case InsertCharacters:
+		return appendControlSequence(dst, '@', int(v))
+	case DeleteCharacters:
+		return appendControlSequence(dst, 'P', int(v))
+	case EraseCharacters:
+		return appendControlSequence(dst, 'X', int(v))
+	case InsertLines:
+		return appendControlSequence(dst, 'L', int(v))
+	case DeleteLines:
+		return appendControlSequence(dst, 'M', int(v))
+	case Tab:
+		if v == 1 {
+			return append(dst, '\t'), nil
+		}
+		return appendControlSequence(dst, 'I', int(v))
+	case BackTab:
+		return appendControlSequence(dst, 'Z', int(v))
+	case SetTabStop:
+		return append(dst, escapeCode, 'H'), nil
+	case ClearTabStop:
+		return appendControlSequence(dst, 'g')
+	case ClearAllTabStops:
+		return appendControlSequence(dst, 'g', 3)
+	case SaveCursorPosition:
+		return appendControlSequence(dst, 's')
+	case RestoreCursorPosition:
+		return appendControlSequence(dst, 'u')
+	case SetTitle:
+		return appendOperatingSystemCommand(dst, oscTitle, string(v)), nil
+	case SetHyperlink:
+		params := ""
+		if v.ID != "" {
+			params = "id=" + v.ID
+		}
+		return appendOperatingSystemCommand(dst, oscHyperlink, params+";"+v.URI), nil
+	case OSC:
+		if v.Code < 0 {
+			return dst, errors.New("ansi: cannot encode negative OSC code " + strconv.Itoa(v.Code))
+		}
+		return appendOperatingSystemCommand(dst, v.Code, string(v.Data)), nil
+	case Unknown:
+		return append(dst, v.Raw...), nil
+	case ControlString:
+		switch v.Kind {
+		case DeviceControlString, StartOfString, PrivacyMessage, ApplicationProgramCommand:
+		default:
+			return dst, errors.New("ansi: cannot encode control string of kind " + v.Kind.String())
+		}
+		dst = append(dst, escapeCode, byte(v.Kind))
+		dst = append(dst, v.Data...)
+		return append(dst, escapeCode, '\\'), nil
+	default:
+		return dst, errors.New("ansi: cannot encode action " + action.ActionString())
+	}
+	return append(dst, sgr.bytes()...), nil
+}
+
+func appendControlSequence(dst []byte, final byte, params ...int) ([]byte, error) {
+	start := len(dst)
+	return appendParams(append(dst, escapeCode, '['), start, final, params)
+}
+
+// appendPrivateControlSequence appends a DEC private control sequence, which
+// starts with "\x1b[?"
+func appendPrivateControlSequence(dst []byte, final byte, params ...int) ([]byte, error) {
+	start := len(dst)
+	return appendParams(append(dst, escapeCode, '[', '?'), start, final, params)
+}
+
+func appendMode(dst []byte, final byte, mode Mode) ([]byte, error) {
+	if mode.Private() {
+		return appendPrivateControlSequence(dst, final, mode.Number())
+	}
+	return appendControlSequence(dst, final, mode.Number())
+}
+
+// appendParams appends the parameters and final byte of a control sequence.
+// On error, dst is truncated to start.
+func appendParams(dst []byte, start int, final byte, params []int) ([]byte, error) {
+	for i, param := range params {
+		if param < 0 {
+			return dst[:start], errors.New("ansi: cannot encode negative parameter " + strconv.Itoa(param))
+		}
+		if i > 0 {
+			dst = append(dst, ';')
+		}
+		dst = strconv.AppendInt(dst, int64(param), 10)
+	}
+	return append(dst, final), nil
+}
+
+// appendOperatingSystemCommand appends an OSC terminated by ST
+func appendOperatingSystemCommand(dst []byte, code int, data string) []byte {
+	dst = append(dst, escapeCode, ']')
+	dst = strconv.AppendInt(dst, int64(code), 10)
+	dst = append(dst, ';')
+	dst = append(dst, data...)
+	return append(dst, escapeCode, '\\')
+}
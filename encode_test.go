@@ -0,0 +1,585 @@
+package ansi_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestStyleTransition(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		from        ansi.Style
+		to          ansi.Style
+		transition  string
+	}{
+		{
+			description: "same style",
+			from:        ansi.Style{Foreground: ansi.Red},
+			to:          ansi.Style{Foreground: ansi.Red},
+			transition:  "",
+		},
+		{
+			description: "to default",
+			from:        ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold},
+			to:          ansi.Style{},
+			transition:  "\x1b[0m",
+		},
+		{
+			description: "adds a modifier",
+			from:        ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold},
+			to:          ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold | ansi.Italic},
+			transition:  "\x1b[3m",
+		},
+		{
+			description: "changes colours",
+			from:        ansi.Style{Foreground: ansi.Red, Background: ansi.Blue, Modifier: ansi.Bold},
+			to:          ansi.Style{Foreground: ansi.BrightRed, Background: ansi.Indexed(208), Modifier: ansi.Bold},
+			transition:  "\x1b[91;48;5;208m",
+		},
+		{
+			description: "removes a modifier that shares a parameter",
+			from:        ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold | ansi.Faint},
+			to:          ansi.Style{Foreground: ansi.Red, Modifier: ansi.Faint},
+			transition:  "\x1b[22;2m",
+		},
+		{
+			description: "prefers resetting when shorter",
+			from:        ansi.Style{Foreground: ansi.Red, Background: ansi.Blue, Modifier: ansi.Bold | ansi.Italic},
+			to:          ansi.Style{Modifier: ansi.Underline},
+			transition:  "\x1b[0;4m",
+		},
+		{
+			description: "changes the underline style",
+			from:        ansi.Style{Modifier: ansi.Underline},
+			to:          ansi.Style{Modifier: ansi.Underline, UnderlineStyle: ansi.CurlyUnderline, UnderlineColor: ansi.Red},
+			transition:  "\x1b[4:3;58;5;1m",
+		},
+		{
+			description: "truecolour",
+			from:        ansi.Style{},
+			to:          ansi.Style{Background: ansi.RGB(255, 136, 0)},
+			transition:  "\x1b[48;2;255;136;0m",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			g.Expect(string(ansi.StyleTransition(tt.from, tt.to))).To(Equal(tt.transition))
+		})
+	}
+}
+
+func randomColor(r *rand.Rand) ansi.Color {
+	switch r.Intn(4) {
+	case 0:
+		return ansi.DefaultColor
+	case 1:
+		return ansi.Color(r.Intn(int(ansi.BrightWhite)) + 1)
+	case 2:
+		return ansi.Indexed(uint8(r.Intn(256)))
+	default:
+		return ansi.RGB(uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)))
+	}
+}
+
+func randomStyle(r *rand.Rand) ansi.Style {
+	style := ansi.Style{
+		Foreground: randomColor(r),
+		Background: randomColor(r),
+		Modifier:   ansi.StyleModifier(r.Intn(int(ansi.Overline) << 1)),
+	}
+	if style.Modifier&ansi.Underline != 0 {
+		style.UnderlineStyle = ansi.UnderlineStyle(r.Intn(int(ansi.DashedUnderline) + 1))
+	}
+	style.UnderlineColor = randomColor(r)
+	if _, ok := style.UnderlineColor.Index(); !ok && style.UnderlineColor != ansi.DefaultColor {
+		// Named underline colours are written as their palette entry
+		style.UnderlineColor = ansi.Indexed(0)
+	}
+	return style
+}
+
+func TestStyleTransition_Random(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := rand.New(rand.NewSource(123))
+
+	for i := 0; i < 1000; i++ {
+		from, to := randomStyle(r), randomStyle(r)
+
+		writer := ansi.NewWriter(&spyOutput{})
+		writer.Style = from
+		_, err := writer.Write(ansi.StyleTransition(from, to))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(writer.Style).To(Equal(to), "from %+v to %+v", from, to)
+	}
+}
+
+func TestLines_WriteANSI(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lines := ansi.Lines{
+		{
+			{Data: ansi.Text("plain ")},
+			{Data: ansi.Text("red"), Style: ansi.Style{Foreground: ansi.Red}},
+			{Data: ansi.Text(" bold red"), Style: ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold}},
+		},
+		{},
+		{
+			{Data: ansi.Text("blue bg"), Style: ansi.Style{Background: ansi.Blue}},
+		},
+		{
+			{Data: ansi.Text("still blue bg"), Style: ansi.Style{Background: ansi.Blue}},
+			{Data: ansi.Text(" plain")},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := lines.WriteANSI(&buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		"plain \x1b[31mred\x1b[1m bold red\n" +
+			"\n" +
+			"\x1b[0;44mblue bg\x1b[0m\n" +
+			"\x1b[44mstill blue bg\x1b[0m plain",
+	))
+
+	var roundTripped ansi.Lines
+	_, err = ansi.NewWriter(&roundTripped).Write(buf.Bytes())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(roundTripped).To(Equal(lines))
+}
+
+func TestLines_WriteANSI_Hyperlinks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lines := ansi.Lines{
+		{
+			{Data: ansi.Text("see ")},
+			{Data: ansi.Text("here"), Link: &ansi.Hyperlink{ID: "1", URI: "https://example.com"}},
+		},
+		{
+			{Data: ansi.Text("still"), Link: &ansi.Hyperlink{ID: "1", URI: "https://example.com"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := lines.WriteANSI(&buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		"see \x1b]8;id=1;https://example.com\x1b\\here\n" +
+			"still\x1b]8;;\x1b\\",
+	))
+
+	var roundTripped ansi.Lines
+	_, err = ansi.NewWriter(&roundTripped).Write(buf.Bytes())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(roundTripped).To(Equal(lines))
+}
+
+func TestLines_WriteANSI_ResetsAtEnd(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lines := ansi.Lines{
+		{
+			{Data: ansi.Text("bold"), Style: ansi.Style{Modifier: ansi.Bold}},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := lines.WriteANSI(&buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(buf.String()).To(Equal("\x1b[1mbold\x1b[0m"))
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		actions     []ansi.Action
+		encoded     string
+	}{
+		{
+			description: "text",
+			actions:     []ansi.Action{ansi.Print("hello"), ansi.Linebreak{}, ansi.Print("world"), ansi.CarriageReturn{}},
+			encoded:     "hello\nworld\r",
+		},
+		{
+			description: "styles",
+			actions: []ansi.Action{
+				ansi.Reset{},
+				ansi.SetForeground(ansi.Red),
+				ansi.SetBackground(ansi.BrightBlue),
+				ansi.SetForeground(ansi.Indexed(208)),
+				ansi.SetBackground(ansi.RGB(1, 2, 3)),
+				ansi.SetBold(true),
+				ansi.SetBold(false),
+				ansi.SetUnderlineStyle(ansi.CurlyUnderline),
+				ansi.SetUnderlineColor(ansi.DefaultColor),
+			},
+			encoded: "\x1b[0m\x1b[31m\x1b[104m\x1b[38;5;208m\x1b[48;2;1;2;3m\x1b[1m\x1b[22m\x1b[4:3m\x1b[59m",
+		},
+		{
+			description: "cursor movement",
+			actions: []ansi.Action{
+				ansi.CursorUp(1),
+				ansi.CursorDown(2),
+				ansi.CursorForward(3),
+				ansi.CursorBack(4),
+				ansi.CursorColumn(0),
+				ansi.CursorPosition(ansi.Pos{Line: 5, Col: 6}),
+				ansi.SaveCursorPosition{},
+				ansi.RestoreCursorPosition{},
+			},
+			encoded: "\x1b[1A\x1b[2B\x1b[3C\x1b[4D\x1b[0G\x1b[5;6H\x1b[s\x1b[u",
+		},
+		{
+			description: "erasure",
+			actions: []ansi.Action{
+				ansi.EraseDisplay(ansi.EraseAll),
+				ansi.EraseLine(ansi.EraseToBeginning),
+			},
+			encoded: "\x1b[2J\x1b[1K",
+		},
+		{
+			description: "operating system commands",
+			actions: []ansi.Action{
+				ansi.SetTitle("title"),
+				ansi.SetHyperlink(ansi.Hyperlink{ID: "1", URI: "https://example.com"}),
+				ansi.SetHyperlink(ansi.Hyperlink{}),
+				ansi.OSC{Code: 52, Data: []byte("c;aGVsbG8=")},
+			},
+			encoded: "\x1b]2;title\x1b\\\x1b]8;id=1;https://example.com\x1b\\\x1b]8;;\x1b\\\x1b]52;c;aGVsbG8=\x1b\\",
+		},
+		{
+			description: "control strings",
+			actions: []ansi.Action{
+				ansi.ControlString{Kind: ansi.DeviceControlString, Data: []byte("q#0")},
+				ansi.ControlString{Kind: ansi.ApplicationProgramCommand, Data: []byte("Gf=100")},
+			},
+			encoded: "\x1bPq#0\x1b\\\x1b_Gf=100\x1b\\",
+		},
+		{
+			description: "tabs",
+			actions: []ansi.Action{
+				ansi.Tab(1),
+				ansi.Tab(2),
+				ansi.BackTab(1),
+				ansi.SetTabStop{},
+				ansi.ClearTabStop{},
+				ansi.ClearAllTabStops{},
+			},
+			encoded: "\t\x1b[2I\x1b[1Z\x1bH\x1b[g\x1b[3g",
+		},
+		{
+			description: "scrolling",
+			actions: []ansi.Action{
+				ansi.SetScrollRegion{Top: 2, Bottom: 10},
+				ansi.ScrollUp(1),
+				ansi.ScrollDown(2),
+				ansi.Index{},
+				ansi.ReverseIndex{},
+			},
+			encoded: "\x1b[2;10r\x1b[1S\x1b[2T\x1bD\x1bM",
+		},
+		{
+			description: "modes",
+			actions: []ansi.Action{
+				ansi.SetMode(ansi.InsertMode),
+				ansi.ResetMode(ansi.CursorVisibleMode),
+			},
+			encoded: "\x1b[4h\x1b[?25l",
+		},
+		{
+			description: "alternate screen",
+			actions: []ansi.Action{
+				ansi.EnterAlternateScreen(ansi.AlternateScreenSaveCursor),
+				ansi.ExitAlternateScreen(ansi.AlternateScreen),
+			},
+			encoded: "\x1b[?1049h\x1b[?47l",
+		},
+		{
+			description: "inserting and deleting",
+			actions: []ansi.Action{
+				ansi.InsertCharacters(1),
+				ansi.DeleteCharacters(2),
+				ansi.EraseCharacters(3),
+				ansi.InsertLines(4),
+				ansi.DeleteLines(5),
+			},
+			encoded: "\x1b[1@\x1b[2P\x1b[3X\x1b[4L\x1b[5M",
+		},
+		{
+			description: "C0 control characters",
+			actions: []ansi.Action{
+				ansi.Backspace{},
+				ansi.Bell{},
+				ansi.VerticalTab{},
+				ansi.FormFeed{},
+			},
+			encoded: "\b\a\v\f",
+		},
+		{
+			description: "unknown sequences are written as-is",
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[?25l")},
+			},
+			encoded: "\x1b[?25l",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var buf bytes.Buffer
+			encoder := ansi.NewEncoder(&buf)
+			for _, action := range tt.actions {
+				err := encoder.Encode(action)
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(buf.String()).To(Equal(tt.encoded))
+		})
+	}
+}
+
+type unencodableAction struct{}
+
+func (unencodableAction) ActionString() string { return "Unencodable" }
+
+func TestEncoder_Encode_Invalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var buf bytes.Buffer
+	encoder := ansi.NewEncoder(&buf)
+	g.Expect(encoder.Encode(unencodableAction{})).To(MatchError("ansi: cannot encode action Unencodable"))
+	g.Expect(encoder.Encode(ansi.CursorUp(-1))).To(HaveOccurred())
+	g.Expect(encoder.Encode(ansi.ControlString{Kind: 'Q'})).To(HaveOccurred())
+	g.Expect(buf.Len()).To(BeZero())
+}
+
+// reparsed returns the actions that the encoding of an action is parsed as,
+// for the actions that AppendAction can't encode exactly
+func reparsed(action ansi.Action) []ansi.Action {
+	switch v := action.(type) {
+	case ansi.SetBold, ansi.SetFaint:
+		if action == ansi.SetBold(false) || action == ansi.SetFaint(false) {
+			return []ansi.Action{ansi.SetBold(false), ansi.SetFaint(false)}
+		}
+	case ansi.SetItalic, ansi.SetFraktur:
+		if action == ansi.SetItalic(false) || action == ansi.SetFraktur(false) {
+			return []ansi.Action{ansi.SetItalic(false), ansi.SetFraktur(false)}
+		}
+	case ansi.SetBlink, ansi.SetRapidBlink:
+		if action == ansi.SetBlink(false) || action == ansi.SetRapidBlink(false) {
+			return []ansi.Action{ansi.SetBlink(false), ansi.SetRapidBlink(false)}
+		}
+	case ansi.SetUnderlineStyle:
+		if v == ansi.SetUnderlineStyle(ansi.StraightUnderline) {
+			return []ansi.Action{ansi.SetUnderline(true)}
+		}
+	case ansi.SetUnderlineColor:
+		if c := ansi.Color(v); c != ansi.DefaultColor && c <= ansi.BrightWhite {
+			return []ansi.Action{ansi.SetUnderlineColor(ansi.Indexed(uint8(c - ansi.Black)))}
+		}
+	}
+	return []ansi.Action{action}
+}
+
+func randomAction(r *rand.Rand) ansi.Action {
+	switch r.Intn(27) {
+	case 0:
+		return ansi.Reset{}
+	case 1:
+		return ansi.SetForeground(randomColor(r))
+	case 2:
+		return ansi.SetBackground(randomColor(r))
+	case 3:
+		return ansi.SetUnderlineColor(randomColor(r))
+	case 4:
+		return ansi.SetUnderlineStyle(ansi.UnderlineStyle(r.Intn(int(ansi.DashedUnderline) + 1)))
+	case 5:
+		enabled := r.Intn(2) == 0
+		return []ansi.Action{ansi.SetBold(enabled), ansi.SetFaint(enabled), ansi.SetItalic(enabled), ansi.SetFraktur(enabled)}[r.Intn(4)]
+	case 6:
+		return ansi.SetUnderline(r.Intn(2) == 0)
+	case 7:
+		enabled := r.Intn(2) == 0
+		return []ansi.Action{ansi.SetBlink(enabled), ansi.SetRapidBlink(enabled)}[r.Intn(2)]
+	case 8:
+		return []ansi.Action{ansi.SetInverted(r.Intn(2) == 0), ansi.SetConceal(r.Intn(2) == 0), ansi.SetStrikethrough(r.Intn(2) == 0)}[r.Intn(3)]
+	case 9:
+		return []ansi.Action{ansi.SetFramed(r.Intn(2) == 0), ansi.SetOverline(r.Intn(2) == 0)}[r.Intn(2)]
+	case 10:
+		return []ansi.Action{ansi.Linebreak{}, ansi.CarriageReturn{}}[r.Intn(2)]
+	case 11:
+		return []ansi.Action{ansi.CursorUp(r.Intn(100)), ansi.CursorDown(r.Intn(100))}[r.Intn(2)]
+	case 12:
+		return []ansi.Action{ansi.CursorForward(r.Intn(100)), ansi.CursorBack(r.Intn(100))}[r.Intn(2)]
+	case 13:
+		return ansi.CursorColumn(r.Intn(100))
+	case 14:
+		return ansi.CursorPosition(ansi.Pos{Line: r.Intn(100), Col: r.Intn(100)})
+	case 15:
+		return ansi.EraseDisplay(r.Intn(4))
+	case 16:
+		return ansi.EraseLine(r.Intn(3))
+	case 17:
+		return []ansi.Action{ansi.SaveCursorPosition{}, ansi.RestoreCursorPosition{}}[r.Intn(2)]
+	case 18:
+		return []ansi.Action{
+			ansi.SetTitle(chars[:r.Intn(10)]),
+			ansi.SetHyperlink(ansi.Hyperlink{ID: chars[:r.Intn(3)], URI: chars[:r.Intn(10)]}),
+			ansi.OSC{Code: r.Intn(200) + 9, Data: []byte(chars[:r.Intn(10)+1])},
+			ansi.ControlString{
+				Kind: []ansi.ControlStringKind{
+					ansi.DeviceControlString,
+					ansi.StartOfString,
+					ansi.PrivacyMessage,
+					ansi.ApplicationProgramCommand,
+				}[r.Intn(4)],
+				Data: []byte(chars[:r.Intn(10)+1]),
+			},
+		}[r.Intn(4)]
+	case 19:
+		return []ansi.Action{
+			ansi.Tab(r.Intn(5) + 1),
+			ansi.BackTab(r.Intn(5) + 1),
+			ansi.SetTabStop{},
+			ansi.ClearTabStop{},
+			ansi.ClearAllTabStops{},
+		}[r.Intn(5)]
+	case 20:
+		return []ansi.Action{
+			ansi.Backspace{},
+			ansi.Bell{},
+			ansi.VerticalTab{},
+			ansi.FormFeed{},
+		}[r.Intn(4)]
+	case 21:
+		n := r.Intn(10)
+		return []ansi.Action{
+			ansi.InsertCharacters(n),
+			ansi.DeleteCharacters(n),
+			ansi.EraseCharacters(n),
+			ansi.InsertLines(n),
+			ansi.DeleteLines(n),
+		}[r.Intn(5)]
+	case 22:
+		return []ansi.Action{
+			ansi.SetScrollRegion{Top: r.Intn(10), Bottom: r.Intn(50)},
+			ansi.ScrollUp(r.Intn(10)),
+			ansi.ScrollDown(r.Intn(10)),
+			ansi.Index{},
+			ansi.ReverseIndex{},
+		}[r.Intn(5)]
+	case 23:
+		mode := []ansi.AlternateScreenMode{
+			ansi.AlternateScreen,
+			ansi.AlternateScreenClearOnExit,
+			ansi.AlternateScreenSaveCursor,
+		}[r.Intn(3)]
+		if r.Intn(2) == 0 {
+			return ansi.EnterAlternateScreen(mode)
+		}
+		return ansi.ExitAlternateScreen(mode)
+	case 24:
+		mode := ansi.Mode(r.Intn(3000))
+		if r.Intn(2) == 0 {
+			mode |= ansi.PrivateMode
+			if mode == ansi.PrivateMode|47 || mode == ansi.PrivateMode|1047 || mode == ansi.PrivateMode|1049 {
+				// Parsed as alternate screen actions
+				mode = ansi.CursorVisibleMode
+			}
+		}
+		if r.Intn(2) == 0 {
+			return ansi.SetMode(mode)
+		}
+		return ansi.ResetMode(mode)
+	default:
+		return ansi.Print(chars[:r.Intn(len(chars)-4)+1])
+	}
+}
+
+func TestEncoder_RoundTrip_Actions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := rand.New(rand.NewSource(789))
+
+	for i := 0; i < 200; i++ {
+		var actions, expected []ansi.Action
+		for len(actions) < 50 {
+			action := randomAction(r)
+			if _, ok := action.(ansi.Print); ok && len(actions) > 0 {
+				// Consecutive prints are parsed as a single print
+				if _, ok := actions[len(actions)-1].(ansi.Print); ok {
+					continue
+				}
+			}
+			actions = append(actions, action)
+			expected = append(expected, reparsed(action)...)
+		}
+
+		var buf bytes.Buffer
+		encoder := ansi.NewEncoder(&buf)
+		for _, action := range actions {
+			g.Expect(encoder.Encode(action)).To(Succeed())
+		}
+
+		g.Expect(ansi.NewParser().ParseAll(buf.Bytes())).To(Equal(expected))
+	}
+}
+
+func TestEncoder_RoundTrip_Inexact(t *testing.T) {
+	for _, tt := range []struct {
+		action ansi.Action
+		parsed []ansi.Action
+	}{
+		{ansi.SetBold(false), []ansi.Action{ansi.SetBold(false), ansi.SetFaint(false)}},
+		{ansi.SetFaint(false), []ansi.Action{ansi.SetBold(false), ansi.SetFaint(false)}},
+		{ansi.SetItalic(false), []ansi.Action{ansi.SetItalic(false), ansi.SetFraktur(false)}},
+		{ansi.SetFraktur(false), []ansi.Action{ansi.SetItalic(false), ansi.SetFraktur(false)}},
+		{ansi.SetBlink(false), []ansi.Action{ansi.SetBlink(false), ansi.SetRapidBlink(false)}},
+		{ansi.SetRapidBlink(false), []ansi.Action{ansi.SetBlink(false), ansi.SetRapidBlink(false)}},
+		{ansi.SetUnderlineStyle(ansi.StraightUnderline), []ansi.Action{ansi.SetUnderline(true)}},
+		{ansi.SetUnderlineColor(ansi.Red), []ansi.Action{ansi.SetUnderlineColor(ansi.Indexed(1))}},
+		{ansi.SetUnderlineColor(ansi.BrightWhite), []ansi.Action{ansi.SetUnderlineColor(ansi.Indexed(15))}},
+	} {
+		t.Run(tt.action.ActionString(), func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			encoded, err := ansi.AppendAction(nil, tt.action)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ansi.NewParser().ParseAll(encoded)).To(Equal(tt.parsed))
+		})
+	}
+}
+
+func TestEncoder_RoundTrip_Bytes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := rand.New(rand.NewSource(1011))
+
+	for i := 0; i < 200; i++ {
+		input := generateEvent(r, 200, 0.1)
+
+		var buf bytes.Buffer
+		encoder := ansi.NewEncoder(&buf)
+		for _, action := range ansi.NewParser().ParseAll(input) {
+			g.Expect(encoder.Encode(action)).To(Succeed())
+		}
+
+		var expected, actual ansi.Lines
+		expectedWriter, actualWriter := ansi.NewWriter(&expected), ansi.NewWriter(&actual)
+		_, err := expectedWriter.Write(input)
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = actualWriter.Write(buf.Bytes())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// Lines may split chunks differently depending on how the prints were
+		// split up, so compare the rendered output
+		var expectedANSI, actualANSI bytes.Buffer
+		g.Expect(expected.WriteANSI(&expectedANSI)).To(Succeed())
+		g.Expect(actual.WriteANSI(&actualANSI)).To(Succeed())
+		g.Expect(actualANSI.String()).To(Equal(expectedANSI.String()), "input: %q", input)
+		g.Expect(actualWriter.State).To(Equal(expectedWriter.State))
+	}
+}
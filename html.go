@@ -0,0 +1,410 @@
+package ansi
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultHTMLClassPrefix = "ansi-"
+
+	// Used when inverting the default colors with inline styles
+	defaultHTMLForeground = "#e5e5e5"
+	defaultHTMLBackground = "#000000"
+)
+
+// The colors used for the 16 named colors, both by HTMLStylesheet and when
+// rendering inline styles. Based on the xterm defaults.
+var htmlPalette = [16]string{
+	"#000000",
+	"#cd0000",
+	"#00cd00",
+	"#cdcd00",
+	"#0000ee",
+	"#cd00cd",
+	"#00cdcd",
+	"#e5e5e5",
+	"#7f7f7f",
+	"#ff0000",
+	"#00ff00",
+	"#ffff00",
+	"#5c5cff",
+	"#ff00ff",
+	"#00ffff",
+	"#ffffff",
+}
+
+var htmlUnderlineStyles = [5]string{
+	"solid",
+	"double",
+	"wavy",
+	"dotted",
+	"dashed",
+}
+
+type htmlRenderer struct {
+	classPrefix      string
+	inlineStyles     bool
+	lineAnchorPrefix string
+}
+
+type HTMLOption func(*htmlRenderer)
+
+// WithHTMLInlineStyles renders styles using the style attribute rather than
+// class names, so that no stylesheet is required. Blinking text is not
+// rendered as blinking.
+func WithHTMLInlineStyles() HTMLOption {
+	return func(r *htmlRenderer) {
+		r.inlineStyles = true
+	}
+}
+
+// WithHTMLClassPrefix sets the prefix of all class names. Defaults to "ansi-".
+func WithHTMLClassPrefix(prefix string) HTMLOption {
+	return func(r *htmlRenderer) {
+		r.classPrefix = prefix
+	}
+}
+
+// WithHTMLLineAnchors gives each line an id of the prefix followed by its
+// 1-based line number, e.g. id="L42" for the prefix "L".
+func WithHTMLLineAnchors(prefix string) HTMLOption {
+	return func(r *htmlRenderer) {
+		r.lineAnchorPrefix = prefix
+	}
+}
+
+func newHTMLRenderer(opts []HTMLOption) *htmlRenderer {
+	r := &htmlRenderer{classPrefix: defaultHTMLClassPrefix}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WriteHTML renders the lines as HTML, intended to be placed within a <pre>
+// element. Each line is a <span> with the class "line" (with the class prefix
+// applied), and lines are separated by "\n". Adjacent chunks that render the
+// same are merged into a single <span>.
+//
+// Hyperlinks are rendered as <a> elements with the class "link", as long as
+// they use one of the schemes http, https, ftp, file or mailto. The ID of a
+// hyperlink, if any, is stored in the data-link-id attribute.
+//
+// Unless WithHTMLInlineStyles is used, the output relies on the class names
+// described by HTMLStylesheet. Colors outside of the 16 named colors are
+// always rendered using inline styles.
+func (l Lines) WriteHTML(w io.Writer, opts ...HTMLOption) error {
+	r := newHTMLRenderer(opts)
+	var buf bytes.Buffer
+	for i, line := range l {
+		buf.Reset()
+		r.writeLine(&buf, i, line)
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *htmlRenderer) writeLine(buf *bytes.Buffer, lineNum int, line Line) {
+	buf.WriteString(`<span class="`)
+	buf.WriteString(html.EscapeString(r.classPrefix))
+	buf.WriteString(`line"`)
+	if r.lineAnchorPrefix != "" {
+		buf.WriteString(` id="`)
+		buf.WriteString(html.EscapeString(r.lineAnchorPrefix))
+		buf.WriteString(strconv.Itoa(lineNum + 1))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	var (
+		openAttrs string
+		openLink  *Hyperlink
+		open      bool
+	)
+	for _, chunk := range line {
+		attrs := r.attributes(chunk.Style)
+		link := htmlLink(chunk.Link)
+		linkChanged := !sameLink(link, openLink)
+		if !open || linkChanged || attrs != openAttrs {
+			if open && openAttrs != "" {
+				buf.WriteString("</span>")
+			}
+			if linkChanged {
+				if openLink != nil {
+					buf.WriteString("</a>")
+				}
+				if link != nil {
+					r.openAnchor(buf, link)
+				}
+			}
+			if attrs != "" {
+				buf.WriteString("<span")
+				buf.WriteString(attrs)
+				buf.WriteByte('>')
+			}
+			openAttrs, openLink, open = attrs, link, true
+		}
+		buf.WriteString(html.EscapeString(string(chunk.Data)))
+	}
+	if open && openAttrs != "" {
+		buf.WriteString("</span>")
+	}
+	if openLink != nil {
+		buf.WriteString("</a>")
+	}
+	buf.WriteString("</span>\n")
+}
+
+func (r *htmlRenderer) openAnchor(buf *bytes.Buffer, link *Hyperlink) {
+	buf.WriteString(`<a class="`)
+	buf.WriteString(html.EscapeString(r.classPrefix))
+	buf.WriteString(`link" href="`)
+	buf.WriteString(html.EscapeString(link.URI))
+	buf.WriteByte('"')
+	if link.ID != "" {
+		buf.WriteString(` data-link-id="`)
+		buf.WriteString(html.EscapeString(link.ID))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+}
+
+// The URI schemes of hyperlinks that are rendered as anchors. Other links
+// (e.g. javascript: URIs) are rendered as plain text, since the terminal
+// output may not be trusted.
+var htmlLinkSchemes = []string{"http", "https", "ftp", "file", "mailto"}
+
+// htmlLink returns the link if it should be rendered as an anchor, and nil
+// otherwise
+func htmlLink(link *Hyperlink) *Hyperlink {
+	if link == nil {
+		return nil
+	}
+	i := strings.IndexByte(link.URI, ':')
+	if i < 0 {
+		return nil
+	}
+	scheme := link.URI[:i]
+	for _, s := range htmlLinkSchemes {
+		if strings.EqualFold(scheme, s) {
+			return link
+		}
+	}
+	return nil
+}
+
+// attributes returns the HTML attributes (with a leading space) for a chunk of
+// the given style, or the empty string if no attributes are required
+func (r *htmlRenderer) attributes(style Style) string {
+	var classes, css []string
+
+	fg, bg := style.Foreground, style.Background
+	if style.Modifier&Inverted != 0 {
+		fg, bg = bg, fg
+		if !r.inlineStyles {
+			classes = append(classes, "inverted")
+		} else {
+			if fg == DefaultColor {
+				css = append(css, "color:"+defaultHTMLBackground)
+			}
+			if bg == DefaultColor {
+				css = append(css, "background-color:"+defaultHTMLForeground)
+			}
+		}
+	}
+	classes, css = r.color(classes, css, "fg-", "color:", fg)
+	classes, css = r.color(classes, css, "bg-", "background-color:", bg)
+
+	if r.inlineStyles {
+		css = inlineModifierStyles(css, style)
+	} else {
+		classes = modifierClasses(classes, style)
+	}
+	if style.Modifier&Underline != 0 {
+		if color, ok := htmlColor(style.UnderlineColor); ok {
+			css = append(css, "text-decoration-color:"+color)
+		}
+	}
+
+	var attrs strings.Builder
+	if len(classes) > 0 {
+		attrs.WriteString(` class="`)
+		for i, class := range classes {
+			if i > 0 {
+				attrs.WriteByte(' ')
+			}
+			attrs.WriteString(html.EscapeString(r.classPrefix + class))
+		}
+		attrs.WriteByte('"')
+	}
+	if len(css) > 0 {
+		attrs.WriteString(` style="`)
+		attrs.WriteString(strings.Join(css, ";"))
+		attrs.WriteByte('"')
+	}
+	return attrs.String()
+}
+
+func (r *htmlRenderer) color(classes, css []string, classPrefix, property string, c Color) ([]string, []string) {
+	if c == DefaultColor {
+		return classes, css
+	}
+	if i, ok := c.Index(); ok && i < 16 {
+		c = Color(i) + Black
+	}
+	if c <= BrightWhite && !r.inlineStyles {
+		return append(classes, classPrefix+c.String()), css
+	}
+	if color, ok := htmlColor(c); ok {
+		css = append(css, property+color)
+	}
+	return classes, css
+}
+
+// htmlColor returns the CSS color for c. ok is false for DefaultColor, and for
+// a named color that doesn't exist.
+func htmlColor(c Color) (color string, ok bool) {
+	if _, _, _, ok := c.RGB(); ok {
+		return c.String(), true
+	}
+	if i, ok := c.Index(); ok {
+		return htmlIndexedColor(i), true
+	}
+	if c < Black || c > BrightWhite {
+		return "", false
+	}
+	return htmlPalette[c-Black], true
+}
+
+// htmlIndexedColor returns the CSS color of an entry in the xterm 256 color
+// palette
+func htmlIndexedColor(i uint8) string {
+	switch {
+	case i < 16:
+		return htmlPalette[i]
+	case i < 232:
+		// 6x6x6 color cube
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return RGB(levels[i/36], levels[i/6%6], levels[i%6]).String()
+	default:
+		// Grayscale ramp
+		level := 8 + 10*(i-232)
+		return RGB(level, level, level).String()
+	}
+}
+
+func modifierClasses(classes []string, style Style) []string {
+	for _, m := range htmlModifiers {
+		if style.Modifier&m.modifier != 0 {
+			classes = append(classes, m.class)
+		}
+	}
+	if style.Modifier&Underline != 0 {
+		classes = append(classes, "underline")
+		if style.UnderlineStyle != StraightUnderline {
+			classes = append(classes, "underline-"+style.UnderlineStyle.String())
+		}
+	}
+	return classes
+}
+
+func inlineModifierStyles(css []string, style Style) []string {
+	for _, m := range htmlModifiers {
+		if style.Modifier&m.modifier != 0 && m.css != "" {
+			css = append(css, m.css)
+		}
+	}
+	var decorations []string
+	if style.Modifier&Underline != 0 {
+		decorations = append(decorations, "underline")
+	}
+	if style.Modifier&Strikethrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+	if style.Modifier&Overline != 0 {
+		decorations = append(decorations, "overline")
+	}
+	if len(decorations) > 0 {
+		css = append(css, "text-decoration-line:"+strings.Join(decorations, " "))
+	}
+	if style.Modifier&Underline != 0 {
+		if style.UnderlineStyle != StraightUnderline && int(style.UnderlineStyle) < len(htmlUnderlineStyles) {
+			css = append(css, "text-decoration-style:"+htmlUnderlineStyles[style.UnderlineStyle])
+		}
+	}
+	return css
+}
+
+// Modifiers other than Inverted and Underline, which need special handling.
+// Strikethrough and Overline have no css since they share a property with
+// Underline.
+var htmlModifiers = []struct {
+	modifier StyleModifier
+	class    string
+	css      string
+}{
+	{Bold, "bold", "font-weight:bold"},
+	{Faint, "faint", "opacity:0.5"},
+	{Italic, "italic", "font-style:italic"},
+	{Blink, "blink", ""},
+	{RapidBlink, "rapid-blink", ""},
+	{Fraktur, "fraktur", "font-family:fraktur"},
+	{Framed, "framed", "border:1px solid"},
+	{Conceal, "conceal", "visibility:hidden"},
+	{Strikethrough, "strikethrough", ""},
+	{Overline, "overline", ""},
+}
+
+// HTMLStylesheet returns a stylesheet for the class names used by
+// Lines.WriteHTML. Only WithHTMLClassPrefix affects the stylesheet.
+func HTMLStylesheet(opts ...HTMLOption) string {
+	r := newHTMLRenderer(opts)
+	p := "." + r.classPrefix
+
+	var b strings.Builder
+	rule := func(selector, declarations string) {
+		b.WriteString(selector)
+		b.WriteString(" { ")
+		b.WriteString(declarations)
+		b.WriteString("; }\n")
+	}
+
+	// Inverted must come before the colors, so that an explicit color takes
+	// precedence over the inverted default color
+	rule(p+"inverted", "color: "+defaultHTMLBackground+"; background-color: "+defaultHTMLForeground)
+	for i, name := range colourNames[Black:] {
+		rule(p+"fg-"+name, "color: "+htmlPalette[i])
+	}
+	for i, name := range colourNames[Black:] {
+		rule(p+"bg-"+name, "background-color: "+htmlPalette[i])
+	}
+	for _, m := range htmlModifiers {
+		if m.css != "" {
+			rule(p+m.class, strings.Replace(m.css, ":", ": ", 1))
+		}
+	}
+	rule(p+"blink", "animation: "+r.classPrefix+"blink 1s step-end infinite")
+	rule(p+"rapid-blink", "animation: "+r.classPrefix+"blink 0.5s step-end infinite")
+	b.WriteString("@keyframes " + r.classPrefix + "blink { 50% { visibility: hidden; } }\n")
+
+	rule(p+"underline", "text-decoration-line: underline")
+	rule(p+"strikethrough", "text-decoration-line: line-through")
+	rule(p+"overline", "text-decoration-line: overline")
+	rule(p+"underline"+p+"strikethrough", "text-decoration-line: underline line-through")
+	rule(p+"underline"+p+"overline", "text-decoration-line: underline overline")
+	rule(p+"strikethrough"+p+"overline", "text-decoration-line: line-through overline")
+	rule(p+"underline"+p+"strikethrough"+p+"overline", "text-decoration-line: underline line-through overline")
+	for i, name := range underlineStyleNames {
+		if UnderlineStyle(i) != StraightUnderline {
+			rule(p+"underline-"+name, "text-decoration-style: "+htmlUnderlineStyles[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package ansi_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestLines_WriteHTML(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		lines       ansi.Lines
+		opts        []ansi.HTMLOption
+		html        string
+	}{
+		{
+			description: "plain text",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("hello")}},
+				{},
+				{{Data: ansi.Text("world")}},
+			},
+			html: `<span class="ansi-line">hello</span>` + "\n" +
+				`<span class="ansi-line"></span>` + "\n" +
+				`<span class="ansi-line">world</span>` + "\n",
+		},
+		{
+			description: "escapes text",
+			lines: ansi.Lines{
+				{{Data: ansi.Text(`<script>alert("&")</script>`)}},
+			},
+			html: `<span class="ansi-line">&lt;script&gt;alert(&#34;&amp;&#34;)&lt;/script&gt;</span>` + "\n",
+		},
+		{
+			description: "styles as classes",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("plain ")},
+					{Data: ansi.Text("bold red"), Style: ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold}},
+					{Data: ansi.Text("curly"), Style: ansi.Style{Modifier: ansi.Underline, UnderlineStyle: ansi.CurlyUnderline}},
+				},
+			},
+			html: `<span class="ansi-line">plain ` +
+				`<span class="ansi-fg-red ansi-bold">bold red</span>` +
+				`<span class="ansi-underline ansi-underline-curly">curly</span>` +
+				`</span>` + "\n",
+		},
+		{
+			description: "merges chunks that render the same",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("red"), Style: ansi.Style{Foreground: ansi.Red}},
+					{Data: ansi.Text(" also red"), Style: ansi.Style{Foreground: ansi.Indexed(1)}},
+				},
+			},
+			html: `<span class="ansi-line"><span class="ansi-fg-red">red also red</span></span>` + "\n",
+		},
+		{
+			description: "colours outside the named colours use inline styles",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("orange"), Style: ansi.Style{Foreground: ansi.Indexed(208), Background: ansi.RGB(1, 2, 3)}},
+					{Data: ansi.Text("grey"), Style: ansi.Style{Foreground: ansi.Indexed(244)}},
+				},
+			},
+			html: `<span class="ansi-line">` +
+				`<span style="color:#ff8700;background-color:#010203">orange</span>` +
+				`<span style="color:#808080">grey</span>` +
+				`</span>` + "\n",
+		},
+		{
+			description: "colours that don't exist are left out",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("unknown"), Style: ansi.Style{
+						Foreground:     ansi.BrightWhite + 1,
+						Modifier:       ansi.Underline,
+						UnderlineColor: ansi.Color(1 << 30),
+					}},
+				},
+			},
+			html: `<span class="ansi-line"><span class="ansi-underline">unknown</span></span>` + "\n",
+		},
+		{
+			description: "inverted swaps colours",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("inverted"), Style: ansi.Style{Foreground: ansi.Red, Modifier: ansi.Inverted}},
+				},
+			},
+			html: `<span class="ansi-line"><span class="ansi-inverted ansi-bg-red">inverted</span></span>` + "\n",
+		},
+		{
+			description: "inline styles",
+			opts:        []ansi.HTMLOption{ansi.WithHTMLInlineStyles()},
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("bold red"), Style: ansi.Style{Foreground: ansi.Red, Modifier: ansi.Bold}},
+					{Data: ansi.Text("inverted"), Style: ansi.Style{Foreground: ansi.Blue, Modifier: ansi.Inverted}},
+					{Data: ansi.Text("decorated"), Style: ansi.Style{
+						Modifier:       ansi.Underline | ansi.Strikethrough,
+						UnderlineStyle: ansi.DottedUnderline,
+						UnderlineColor: ansi.RGB(255, 0, 0),
+					}},
+				},
+			},
+			html: `<span class="ansi-line">` +
+				`<span style="color:#cd0000;font-weight:bold">bold red</span>` +
+				`<span style="color:#000000;background-color:#0000ee">inverted</span>` +
+				`<span style="text-decoration-line:underline line-through;text-decoration-style:dotted;text-decoration-color:#ff0000">decorated</span>` +
+				`</span>` + "\n",
+		},
+		{
+			description: "hyperlinks",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("see ")},
+					{Data: ansi.Text("the "), Link: &ansi.Hyperlink{URI: "https://example.com/?a=1&b=2"}},
+					{Data: ansi.Text("docs"), Style: ansi.Style{Modifier: ansi.Bold}, Link: &ansi.Hyperlink{URI: "https://example.com/?a=1&b=2"}},
+					{Data: ansi.Text(" and "), Style: ansi.Style{Modifier: ansi.Bold}},
+					{Data: ansi.Text("file"), Style: ansi.Style{Modifier: ansi.Bold}, Link: &ansi.Hyperlink{ID: "f", URI: "file:///tmp/file"}},
+				},
+				{
+					{Data: ansi.Text("unsafe"), Link: &ansi.Hyperlink{URI: "javascript:alert(1)"}},
+				},
+			},
+			html: `<span class="ansi-line">see ` +
+				`<a class="ansi-link" href="https://example.com/?a=1&amp;b=2">the <span class="ansi-bold">docs</span></a>` +
+				`<span class="ansi-bold"> and </span>` +
+				`<a class="ansi-link" href="file:///tmp/file" data-link-id="f"><span class="ansi-bold">file</span></a>` +
+				`</span>` + "\n" +
+				`<span class="ansi-line">unsafe</span>` + "\n",
+		},
+		{
+			description: "custom class prefix and line anchors",
+			opts:        []ansi.HTMLOption{ansi.WithHTMLClassPrefix("x-"), ansi.WithHTMLLineAnchors("L")},
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one"), Style: ansi.Style{Modifier: ansi.Italic}}},
+				{{Data: ansi.Text("two")}},
+			},
+			html: `<span class="x-line" id="L1"><span class="x-italic">one</span></span>` + "\n" +
+				`<span class="x-line" id="L2">two</span>` + "\n",
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var buf bytes.Buffer
+			err := tt.lines.WriteHTML(&buf, tt.opts...)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(buf.String()).To(Equal(tt.html))
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("failed")
+}
+
+func TestLines_WriteHTML_Error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lines := ansi.Lines{{{Data: ansi.Text("hello")}}}
+	err := lines.WriteHTML(failingWriter{})
+	g.Expect(err).To(MatchError("failed"))
+}
+
+func TestHTMLStylesheet(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	stylesheet := ansi.HTMLStylesheet(ansi.WithHTMLClassPrefix("x-"))
+	g.Expect(stylesheet).To(ContainSubstring(".x-fg-red { color: #cd0000; }\n"))
+	g.Expect(stylesheet).To(ContainSubstring(".x-bg-bright-white { background-color: #ffffff; }\n"))
+	g.Expect(stylesheet).To(ContainSubstring(".x-bold { font-weight: bold; }\n"))
+	g.Expect(stylesheet).To(ContainSubstring(".x-underline-curly { text-decoration-style: wavy; }\n"))
+	g.Expect(strings.Index(stylesheet, ".x-inverted")).To(BeNumerically("<", strings.Index(stylesheet, ".x-fg-black")))
+}
@@ -34,13 +34,54 @@ func (t *Text) UnmarshalJSON(data []byte) error {
 type Chunk struct {
 	Data  Text  `json:"data"`
 	Style Style `json:"style"`
+	// Link is the OSC 8 hyperlink that the chunk belongs to, if any
+	Link *Hyperlink `json:"link,omitempty"`
+	// Wrapped is set on the last chunk of a line that was soft-wrapped, i.e.
+	// the text continues on the next line
+	Wrapped bool `json:"wrapped,omitempty"`
+}
+
+// hasFormat returns whether the chunk has the given style and link, in which
+// case data printed with them can be merged into the chunk
+func (c Chunk) hasFormat(style Style, link *Hyperlink) bool {
+	if c.Style != style {
+		return false
+	}
+	return sameLink(c.Link, link)
+}
+
+func sameLink(a, b *Hyperlink) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 type Line = []Chunk
 
+// isWrapped returns whether line was soft-wrapped onto the next line
+func isWrapped(line Line) bool {
+	return len(line) > 0 && line[len(line)-1].Wrapped
+}
+
+// setWrapped marks whether line was soft-wrapped, which is kept on its last
+// chunk. Before adding chunks to the end of a line, the mark must be removed
+// and then set again.
+func setWrapped(line Line, wrapped bool) {
+	if len(line) > 0 {
+		line[len(line)-1].Wrapped = wrapped
+	}
+}
+
 type Lines []Line
 
 func (l *Lines) Print(data []byte, style Style, pos Pos) error {
+	return l.PrintHyperlink(data, style, nil, pos)
+}
+
+// PrintHyperlink prints data that belongs to a hyperlink. Chunks are split
+// wherever the link changes, even if the style does not.
+func (l *Lines) PrintHyperlink(data []byte, style Style, link *Hyperlink, pos Pos) error {
 	if pos.Line < 0 {
 		pos.Line = 0
 	}
@@ -53,134 +94,174 @@ func (l *Lines) Print(data []byte, style Style, pos Pos) error {
 		numEmpty--
 	}
 	if pos.Line >= len(*l) {
-		spacerLen := pos.Col
-		newData := make([]byte, spacerLen+len(data))
-		copy(newData, spacer(spacerLen))
-		copy(newData[spacerLen:], data)
-		*l = append(*l, Line{{Data: newData, Style: style}})
+		*l = append(*l, Line{})
+		l.addFirstChunk(data, style, link, pos)
 		return nil
 	}
 
-	lineLen := l.lineLength(pos.Line)
-
-	if pos.Col >= lineLen {
-		l.appendToLine(data, style, pos)
+	i, chunkStart := findCol((*l)[pos.Line], 0, 0, pos.Col)
+	if i < len((*l)[pos.Line]) && len(data) == 1 && data[0] == '\t' {
+		// Like in a terminal, a tab moves over text rather than erasing it,
+		// so a literal tab is only kept where there is no text yet
+		return nil
+	}
+	wrapped := isWrapped((*l)[pos.Line])
+	setWrapped((*l)[pos.Line], false)
+	if i == len((*l)[pos.Line]) {
+		// chunkStart is the width of the line
+		l.appendToLine(data, style, link, pos, chunkStart)
 	} else {
-		l.insertWithinLine(data, style, pos)
+		l.insertWithinLine(data, style, link, pos, i, chunkStart)
+	}
+	setWrapped((*l)[pos.Line], wrapped)
+	return nil
+}
+
+// MarkWrapped marks line as soft-wrapped, so that it can be joined with the
+// next line, e.g. by PlainText
+func (l Lines) MarkWrapped(line int) error {
+	if line < 0 || line >= len(l) {
+		return nil
 	}
+	setWrapped(l[line], true)
 	return nil
 }
 
-func (l Lines) appendToLine(data []byte, style Style, pos Pos) {
+func (l Lines) appendToLine(data []byte, style Style, link *Hyperlink, pos Pos, lineWidth int) {
 	line := l[pos.Line]
 
-	lineLen := l.lineLength(pos.Line)
-	spacerLen := pos.Col - lineLen
+	spacerLen := pos.Col - lineWidth
 
 	if len(line) == 0 {
-		l.addFirstChunk(data, style, pos)
+		l.addFirstChunk(data, style, link, pos)
 		return
 	}
 
 	lastChunk := &line[len(line)-1]
-	lastChunk.Data = append(lastChunk.Data, spacer(spacerLen)...)
-	if lastChunk.Style == style {
+	if spacerLen > 0 && lastChunk.Link != nil && !sameLink(lastChunk.Link, link) {
+		// The gap after a hyperlink is not part of it
+		line = append(line, Chunk{Data: append(Text(nil), spacer(spacerLen)...), Style: lastChunk.Style})
+		l[pos.Line] = line
+		lastChunk = &line[len(line)-1]
+	} else {
+		lastChunk.Data = append(lastChunk.Data, spacer(spacerLen)...)
+	}
+	if lastChunk.hasFormat(style, link) {
 		lastChunk.Data = append(lastChunk.Data, data...)
 		return
 	}
 	newData := make([]byte, len(data))
 	copy(newData, data)
-	l[pos.Line] = append(line, Chunk{Data: newData, Style: style})
+	l[pos.Line] = append(line, Chunk{Data: newData, Style: style, Link: link})
 }
 
-func (l Lines) addFirstChunk(data []byte, style Style, pos Pos) {
+func (l Lines) addFirstChunk(data []byte, style Style, link *Hyperlink, pos Pos) {
+	if link != nil && pos.Col > 0 {
+		// The gap before a hyperlink is not part of it
+		l[pos.Line] = Line{
+			{Data: append(Text(nil), spacer(pos.Col)...), Style: style},
+			{Data: append(Text(nil), data...), Style: style, Link: link},
+		}
+		return
+	}
 	newData := make([]byte, pos.Col+len(data))
 	copy(newData, spacer(pos.Col))
 	copy(newData[pos.Col:], data)
-	l[pos.Line] = Line{{Data: newData, Style: style}}
+	l[pos.Line] = Line{{Data: newData, Style: style, Link: link}}
 }
 
-func (l Lines) insertWithinLine(data []byte, style Style, pos Pos) {
+// insertWithinLine overwrites the cells of a line that data covers, starting
+// within the i-th chunk
+func (l Lines) insertWithinLine(data []byte, style Style, link *Hyperlink, pos Pos, i, chunkStart int) {
 	line := l[pos.Line]
-	chunkStart := 0
-	for i := 0; i < len(line); i++ {
-		chunk := line[i]
-		chunkInterval := intervalWithWidth(chunkStart, len(chunk.Data))
-		printInterval := intervalWithWidth(pos.Col, len(data))
+	endCol := pos.Col + textWidth(data, pos.Col)
+	j, endChunkStart := findCol(line, i, chunkStart, endCol)
 
-		relCol := pos.Col - chunkStart
-		chunkStart += len(chunk.Data)
+	leftData, _ := splitText(line[i].Data, chunkStart, pos.Col)
+	var rightData Text
+	if j < len(line) {
+		_, rightData = splitText(line[j].Data, endChunkStart, endCol)
+	}
 
-		if !chunkInterval.overlaps(printInterval) {
-			continue
-		}
+	if i == j && line[i].hasFormat(style, link) && len(leftData)+len(data)+len(rightData) == len(line[i].Data) {
+		// Minor optimization: overwrite the chunk in place
+		copy(line[i].Data[len(leftData):], data)
+		return
+	}
+
+	newData := make([]byte, len(data))
+	copy(newData, data)
 
-		if chunkInterval.contains(printInterval) {
-			l.insertInsideChunk(data, style, pos.Line, relCol, i)
-			return
+	newLine := make(Line, 0, len(line)+2)
+	newLine = appendChunks(newLine, line[:i]...)
+	newLine = appendChunks(newLine, Chunk{Data: leftData, Style: line[i].Style, Link: line[i].Link})
+	newLine = appendChunks(newLine, Chunk{Data: newData, Style: style, Link: link})
+	if j < len(line) {
+		newLine = appendChunks(newLine, Chunk{Data: rightData, Style: line[j].Style, Link: line[j].Link})
+		newLine = appendChunks(newLine, line[j+1:]...)
+	}
+	l[pos.Line] = newLine
+}
+
+// findCol returns the index of the chunk that contains col, and the column
+// that the chunk starts at, searching from the i-th chunk, which starts at
+// chunkStart. If col is beyond the end of the line, the index is len(line)
+// and the column is the width of the line.
+func findCol(line Line, i, chunkStart, col int) (int, int) {
+	for ; i < len(line); i++ {
+		chunkWidth := textWidth(line[i].Data, chunkStart)
+		if chunkStart+chunkWidth > col {
+			break
 		}
-		newLine := append(make(Line, 0, len(line)+1), line[:i]...)
-		originalChunkLength := len(chunk.Data)
-
-		chunk.Data = chunk.Data[:relCol]
-		if chunk.Style == style {
-			chunk.Data = append(chunk.Data, data...)
-			newLine = append(newLine, chunk)
-		} else {
-			if len(chunk.Data) > 0 {
-				newLine = append(newLine, chunk)
+		chunkStart += chunkWidth
+	}
+	return i, chunkStart
+}
+
+// splitText splits data, which starts at column start, into the cells before
+// col, and the cells from col onwards, never splitting up a grapheme cluster.
+// If col falls within a wide character (or a tab), the character is replaced
+// by spaces.
+//
+// The data is shared with the original, but appending to the left half never
+// overwrites the right half.
+func splitText(data []byte, start, col int) (Text, Text) {
+	i, pos := 0, start
+	for i < len(data) {
+		n, width := graphemeAt(data, i, pos)
+		if pos+width > col {
+			if pos == col {
+				return data[:i:i], data[i:]
 			}
-			newData := make([]byte, len(data))
-			copy(newData, data)
-			newLine = append(newLine, Chunk{Data: newData, Style: style})
+			// The cluster straddles col, so blank it out
+			left := append(data[:i:i], spacer(col-pos)...)
+			right := append(spacer(pos+width-col), data[i+n:]...)
+			return left, right
 		}
-
-		bytesToRemove := len(data) - originalChunkLength + relCol
-		l.removeBytesInLine(bytesToRemove, i, pos.Line, &newLine)
-		l[pos.Line] = newLine
-		return
+		pos += width
+		i += n
 	}
+	return data, nil
 }
 
-func (l Lines) insertInsideChunk(data []byte, style Style, lineNum, relCol int, chunkIndex int) {
-	chunk := l[lineNum][chunkIndex]
-	if chunk.Style == style {
-		copy(l[lineNum][chunkIndex].Data[relCol:], data)
-		return
-	}
-	line := l[lineNum]
-	newLine := make(Line, 0, len(line)+2)
-	newLine = append(newLine, line[:chunkIndex]...)
-	if relCol > 0 {
-		leftChunk := chunk
-		leftChunk.Data = leftChunk.Data[:relCol]
-		newLine = append(newLine, leftChunk)
-	}
-	newLine = append(newLine, Chunk{Data: data, Style: style})
-	if relCol+len(data) < len(chunk.Data) {
-		rightChunk := chunk
-		rightChunk.Data = rightChunk.Data[relCol+len(data):]
-		newLine = append(newLine, rightChunk)
-	}
-	newLine = append(newLine, line[chunkIndex+1:]...)
-	l[lineNum] = newLine
-	return
-}
-
-func (l Lines) removeBytesInLine(bytesToRemove int, chunkIndex int, lineNum int, newLine *Line) {
-	line := l[lineNum]
-	for i := chunkIndex + 1; i < len(line); i++ {
-		chunk := line[i]
-		if bytesToRemove >= len(chunk.Data) {
-			bytesToRemove -= len(chunk.Data)
+// appendChunks appends chunks to a line, merging chunks with the same format
+// and dropping empty chunks
+func appendChunks(line Line, chunks ...Chunk) Line {
+	for _, chunk := range chunks {
+		if len(chunk.Data) == 0 {
 			continue
 		}
-		if bytesToRemove > 0 {
-			line[i].Data = chunk.Data[bytesToRemove:]
+		if len(line) > 0 {
+			last := &line[len(line)-1]
+			if last.hasFormat(chunk.Style, chunk.Link) {
+				// Never append in place, since the data may be shared
+				last.Data = append(last.Data[:len(last.Data):len(last.Data)], chunk.Data...)
+				continue
+			}
 		}
-		*newLine = append(*newLine, line[i:]...)
-		return
+		line = append(line, chunk)
 	}
+	return line
 }
 
 func (l Lines) lineLength(i int) int {
@@ -191,6 +272,8 @@ func (l Lines) lineLength(i int) int {
 	return length
 }
 
+// ClearRight clears the line from pos onwards, after which the line is no
+// longer soft-wrapped
 func (l Lines) ClearRight(pos Pos) error {
 	if pos.Line < 0 || pos.Line >= len(l) {
 		return nil
@@ -199,22 +282,180 @@ func (l Lines) ClearRight(pos Pos) error {
 		pos.Col = 0
 	}
 	line := l[pos.Line]
-	chunkEnd := 0
-	for i := 0; i < len(line); i++ {
-		chunk := &line[i]
-		chunkStart := chunkEnd
-		chunkEnd += len(chunk.Data)
-		if chunkEnd < pos.Col {
-			continue
-		}
-		chunk.Data = chunk.Data[:pos.Col-chunkStart]
-		keepUpToChunk := i
-		if len(chunk.Data) == 0 {
-			keepUpToChunk--
-		}
-		l[pos.Line] = line[:keepUpToChunk+1]
+	setWrapped(line, false)
+	i, chunkStart := findCol(line, 0, 0, pos.Col)
+	if i == len(line) {
 		return nil
 	}
+	line[i].Data, _ = splitText(line[i].Data, chunkStart, pos.Col)
+	if len(line[i].Data) == 0 {
+		i--
+	}
+	l[pos.Line] = line[:i+1]
+	return nil
+}
+
+// InsertCharacters inserts n unstyled spaces at pos. Nothing is inserted
+// beyond the end of the line.
+func (l Lines) InsertCharacters(n int, pos Pos) error {
+	if n <= 0 || pos.Line < 0 || pos.Line >= len(l) {
+		return nil
+	}
+	left, right := splitLine(l[pos.Line], pos.Col)
+	if len(right) == 0 {
+		return nil
+	}
+	newData := make([]byte, n)
+	copy(newData, spacer(n))
+	wrapped := isWrapped(l[pos.Line])
+	newLine := appendChunks(left, Chunk{Data: newData})
+	l[pos.Line] = appendChunks(newLine, right...)
+	setWrapped(l[pos.Line], wrapped)
+	return nil
+}
+
+// DeleteCharacters removes n cells at pos, keeping the style of the cells
+// after them
+func (l Lines) DeleteCharacters(n int, pos Pos) error {
+	if n <= 0 || pos.Line < 0 || pos.Line >= len(l) {
+		return nil
+	}
+	left, _ := splitLine(l[pos.Line], pos.Col)
+	_, right := splitLine(l[pos.Line], pos.Col+n)
+	wrapped := isWrapped(l[pos.Line])
+	l[pos.Line] = appendChunks(left, right...)
+	setWrapped(l[pos.Line], wrapped)
+	return nil
+}
+
+// InsertLines inserts n empty lines before line. Nothing is inserted beyond
+// the last line.
+func (l *Lines) InsertLines(n int, line int) error {
+	if n <= 0 || line < 0 || line >= len(*l) {
+		return nil
+	}
+	newLines := make(Lines, len(*l)+n)
+	copy(newLines, (*l)[:line])
+	for i := line; i < line+n; i++ {
+		newLines[i] = Line{}
+	}
+	copy(newLines[line+n:], (*l)[line:])
+	*l = newLines
+	return nil
+}
+
+// DeleteLines removes n lines, starting at line
+func (l *Lines) DeleteLines(n int, line int) error {
+	if n <= 0 || line < 0 || line >= len(*l) {
+		return nil
+	}
+	if line+n > len(*l) {
+		n = len(*l) - line
+	}
+	*l = append((*l)[:line], (*l)[line+n:]...)
+	return nil
+}
+
+// ScrollUp moves the lines from top to bottom up n lines
+func (l *Lines) ScrollUp(n int, top, bottom int) error {
+	if top < 0 {
+		top = 0
+	}
+	if n <= 0 || top > bottom || top >= len(*l) {
+		return nil
+	}
+	if n > bottom-top+1 {
+		n = bottom - top + 1
+	}
+	if bottom >= len(*l) {
+		// The region extends beyond the last line, so the lines that fill in
+		// at the bottom don't need to exist
+		return l.DeleteLines(n, top)
+	}
+	region := (*l)[top : bottom+1]
+	copy(region, region[n:])
+	for i := len(region) - n; i < len(region); i++ {
+		region[i] = Line{}
+	}
+	return nil
+}
+
+// ScrollDown moves the lines from top to bottom down n lines
+func (l *Lines) ScrollDown(n int, top, bottom int) error {
+	if top < 0 {
+		top = 0
+	}
+	if n <= 0 || top > bottom || top >= len(*l) {
+		return nil
+	}
+	if n > bottom-top+1 {
+		n = bottom - top + 1
+	}
+	if bottom >= len(*l) {
+		// Only the lines that exist need to move
+		newLen := len(*l) + n
+		if newLen > bottom+1 {
+			newLen = bottom + 1
+		}
+		*l = append(*l, make(Lines, newLen-len(*l))...)
+		bottom = newLen - 1
+	}
+	region := (*l)[top : bottom+1]
+	copy(region[n:], region)
+	for i := 0; i < n; i++ {
+		region[i] = Line{}
+	}
+	return nil
+}
+
+// splitLine returns the chunks of a line before col, and the chunks from col
+// onwards. Neither shares chunks with the original line.
+func splitLine(line Line, col int) (Line, Line) {
+	if col < 0 {
+		col = 0
+	}
+	i, chunkStart := findCol(line, 0, 0, col)
+	if i == len(line) {
+		return append(Line(nil), line...), nil
+	}
+	leftData, rightData := splitText(line[i].Data, chunkStart, col)
+	left := make(Line, 0, i+2)
+	left = appendChunks(left, line[:i]...)
+	left = appendChunks(left, Chunk{Data: leftData, Style: line[i].Style, Link: line[i].Link})
+	right := make(Line, 0, len(line)-i+1)
+	right = appendChunks(right, Chunk{Data: rightData, Style: line[i].Style, Link: line[i].Link})
+	right = appendChunks(right, line[i+1:]...)
+	return left, right
+}
+
+// ClearBelow removes all lines after line
+func (l *Lines) ClearBelow(line int) error {
+	if line < 0 {
+		line = -1
+	}
+	if line+1 < len(*l) {
+		*l = (*l)[:line+1]
+	}
+	return nil
+}
+
+// ClearAbove empties all lines before line
+func (l *Lines) ClearAbove(line int) error {
+	for i := 0; i < line && i < len(*l); i++ {
+		(*l)[i] = Line{}
+	}
+	return nil
+}
+
+// ClearAll removes all lines
+func (l *Lines) ClearAll() error {
+	*l = (*l)[:0]
+	return nil
+}
+
+// ClearScrollback does nothing, since Lines has no scrollback of its own. A
+// Writer deletes the lines it kept when scrolling before calling it.
+func (l *Lines) ClearScrollback() error {
 	return nil
 }
 
@@ -222,9 +463,10 @@ func spacer(length int) []byte {
 	if length <= 0 {
 		return nil
 	}
-	// Minor optimization: if spacer is small enough, don't need to perform a heap alloc
+	// Minor optimization: if spacer is small enough, don't need to perform a heap alloc.
+	// The capacity is limited so that appending to the spacer can't modify it
 	if length <= spacerBytesSize {
-		return spacerPreallocBytes[:length]
+		return spacerPreallocBytes[:length:length]
 	}
 	return bytes.Repeat([]byte{' '}, length)
 }
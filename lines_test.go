@@ -437,6 +437,205 @@ func TestLines_Print(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "columns are measured in cells",
+			printCalls: []printCall{
+				{
+					data: []byte("日本語"),
+					pos:  ansi.Pos{Line: 0, Col: 0},
+				},
+				{
+					data:  []byte("x"),
+					pos:   ansi.Pos{Line: 0, Col: 2},
+					style: ansi.Style{Modifier: ansi.Bold},
+				},
+				{
+					data: []byte("!"),
+					pos:  ansi.Pos{Line: 0, Col: 7},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("日"),
+					},
+					{
+						Data:  []byte("x"),
+						Style: ansi.Style{Modifier: ansi.Bold},
+					},
+					{
+						Data: []byte(" 語 !"),
+					},
+				},
+			},
+		},
+		{
+			description: "grapheme clusters are not split up",
+			printCalls: []printCall{
+				{
+					data: []byte("cafe\u0301 \U0001f468\u200d\U0001f469\u200d\U0001f467 \U0001f1ef\U0001f1f5 \u2764\ufe0f!"),
+					pos:  ansi.Pos{Line: 0, Col: 0},
+				},
+				{
+					data: []byte("E"),
+					pos:  ansi.Pos{Line: 0, Col: 3},
+				},
+				{
+					data: []byte("_"),
+					pos:  ansi.Pos{Line: 0, Col: 6},
+				},
+				{
+					data: []byte("_"),
+					pos:  ansi.Pos{Line: 0, Col: 9},
+				},
+				{
+					data: []byte("_"),
+					pos:  ansi.Pos{Line: 0, Col: 12},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("cafE  _  _  _!"),
+					},
+				},
+			},
+		},
+		{
+			description: "overwriting part of a wide character blanks out the rest",
+			printCalls: []printCall{
+				{
+					data: []byte("a日b"),
+					pos:  ansi.Pos{Line: 0, Col: 0},
+				},
+				{
+					data:  []byte("x"),
+					pos:   ansi.Pos{Line: 0, Col: 1},
+					style: ansi.Style{Modifier: ansi.Bold},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("a"),
+					},
+					{
+						Data:  []byte("x"),
+						Style: ansi.Style{Modifier: ansi.Bold},
+					},
+					{
+						Data: []byte(" b"),
+					},
+				},
+			},
+		},
+		{
+			description: "chunks are split on hyperlink boundaries",
+			printCalls: []printCall{
+				{
+					data: []byte("see "),
+					pos:  ansi.Pos{Line: 0, Col: 0},
+				},
+				{
+					data: []byte("here"),
+					pos:  ansi.Pos{Line: 0, Col: 4},
+					link: &ansi.Hyperlink{URI: "https://example.com"},
+				},
+				{
+					data: []byte("!"),
+					pos:  ansi.Pos{Line: 0, Col: 8},
+					link: &ansi.Hyperlink{URI: "https://example.com"},
+				},
+				{
+					data: []byte("xx"),
+					pos:  ansi.Pos{Line: 0, Col: 5},
+					link: &ansi.Hyperlink{ID: "other", URI: "https://example.com"},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("see "),
+					},
+					{
+						Data: []byte("h"),
+						Link: &ansi.Hyperlink{URI: "https://example.com"},
+					},
+					{
+						Data: []byte("xx"),
+						Link: &ansi.Hyperlink{ID: "other", URI: "https://example.com"},
+					},
+					{
+						Data: []byte("e!"),
+						Link: &ansi.Hyperlink{URI: "https://example.com"},
+					},
+				},
+			},
+		},
+		{
+			description: "widths are kept up to date as the line changes",
+			printCalls: []printCall{
+				{
+					data: []byte("e"),
+					pos:  ansi.Pos{Line: 0, Col: 0},
+				},
+				{
+					data: []byte("\u0301"),
+					pos:  ansi.Pos{Line: 0, Col: 1},
+				},
+				{
+					data: []byte("世"),
+					pos:  ansi.Pos{Line: 0, Col: 1},
+				},
+				{
+					data: []byte("x"),
+					pos:  ansi.Pos{Line: 0, Col: 3},
+				},
+				{
+					data: []byte("y"),
+					pos:  ansi.Pos{Line: 0, Col: 1},
+				},
+				{
+					data: []byte("z"),
+					pos:  ansi.Pos{Line: 0, Col: 4},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("e\u0301y xz"),
+					},
+				},
+			},
+		},
+		{
+			description: "gaps before and after a hyperlink are not part of it",
+			printCalls: []printCall{
+				{
+					data: []byte("foo"),
+					pos:  ansi.Pos{Line: 0, Col: 2},
+					link: &ansi.Hyperlink{URI: "https://example.com"},
+				},
+				{
+					data: []byte("bar"),
+					pos:  ansi.Pos{Line: 0, Col: 8},
+				},
+			},
+			lines: ansi.Lines{
+				{
+					{
+						Data: []byte("  "),
+					},
+					{
+						Data: []byte("foo"),
+						Link: &ansi.Hyperlink{URI: "https://example.com"},
+					},
+					{
+						Data: []byte("   bar"),
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tt.description, func(t *testing.T) {
 			g := NewGomegaWithT(t)
@@ -449,7 +648,11 @@ func TestLines_Print(t *testing.T) {
 				printCalls[i].data = make([]byte, len(pc.data))
 				copy(printCalls[i].data, pc.data)
 
-				out.Print(pc.data, pc.style, pc.pos)
+				if pc.link != nil {
+					out.PrintHyperlink(pc.data, pc.style, pc.link, pc.pos)
+				} else {
+					out.Print(pc.data, pc.style, pc.pos)
+				}
 			}
 
 			g.Expect(out).To(Equal(tt.lines))
@@ -646,3 +849,23 @@ func TestText_UnmarshalJSON(t *testing.T) {
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(text).To(Equal(ansi.Text("hello world\x1b")))
 }
+
+func TestChunk_MarshalJSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	chunks := []ansi.Chunk{
+		{Data: ansi.Text("plain")},
+		{Data: ansi.Text("link"), Link: &ansi.Hyperlink{ID: "1", URI: "https://example.com"}},
+	}
+	marshalled, err := json.Marshal(chunks)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(marshalled).To(MatchJSON(`[
+		{"data": "plain", "style": {}},
+		{"data": "link", "style": {}, "link": {"id": "1", "uri": "https://example.com"}}
+	]`))
+
+	var unmarshalled []ansi.Chunk
+	err = json.Unmarshal(marshalled, &unmarshalled)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(unmarshalled).To(Equal(chunks))
+}
@@ -0,0 +1,113 @@
+package ansi
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// https://invisible-island.net/xterm/ctlseqs/ctlseqs.html#h2-Operating-System-Commands
+
+const bell = '\a'
+
+// OSC codes with a more specific Action
+const (
+	oscIconNameAndTitle = 0
+	oscTitle            = 2
+	oscHyperlink        = 8
+)
+
+// parseOperatingSystemCommand reads an OSC, which is terminated by either BEL
+// or ST ("ESC \")
+func parseOperatingSystemCommand(p *Parser, input []byte) stateFn {
+	for {
+		c, ok := p.next(input)
+		if !ok {
+			return parseOperatingSystemCommand
+		}
+		switch c {
+		case bell:
+			p.dispatchOperatingSystemCommand("\a")
+			return parseBytes
+		case escapeCode:
+			return parseOperatingSystemCommandEscape
+		case cancel, substitute:
+			p.ignore()
+			return parseBytes
+		default:
+			p.appendControlString(c)
+		}
+	}
+}
+
+func parseOperatingSystemCommandEscape(p *Parser, input []byte) stateFn {
+	c, ok := p.next(input)
+	if !ok {
+		return parseOperatingSystemCommandEscape
+	}
+	if c != '\\' {
+		// Any other escape sequence aborts the OSC
+		p.backup()
+		p.ignore()
+		p.beginEscape()
+		return parseEscapeSequence
+	}
+	p.dispatchOperatingSystemCommand("\x1b\\")
+	return parseBytes
+}
+
+// dispatchOperatingSystemCommand emits the action for the buffered OSC, which
+// was terminated by terminator
+func (p *Parser) dispatchOperatingSystemCommand(terminator string) {
+	codeBytes, data := p.str, []byte(nil)
+	if i := bytes.IndexByte(p.str, ';'); i >= 0 {
+		codeBytes, data = p.str[:i], p.str[i+1:]
+	}
+	code, err := strconv.Atoi(string(codeBytes))
+	if err != nil || code < 0 {
+		p.emitUnknownOperatingSystemCommand(terminator)
+		return
+	}
+	switch code {
+	case oscIconNameAndTitle, oscTitle:
+		if p.strTruncated {
+			// A title that was cut short would be wrong
+			p.emitUnknownOperatingSystemCommand(terminator)
+			return
+		}
+		p.emit(SetTitle(data))
+	case oscHyperlink:
+		link, ok := parseHyperlink(data)
+		if !ok || p.strTruncated {
+			p.emitUnknownOperatingSystemCommand(terminator)
+			return
+		}
+		p.emit(SetHyperlink(link))
+	default:
+		p.emit(OSC{Code: code, Data: append([]byte(nil), data...)})
+	}
+}
+
+func (p *Parser) emitUnknownOperatingSystemCommand(terminator string) {
+	raw := make([]byte, 0, len(p.str)+len(terminator)+2)
+	raw = append(raw, escapeCode, ']')
+	raw = append(raw, p.str...)
+	raw = append(raw, terminator...)
+	p.emit(Unknown{Raw: raw})
+}
+
+// parseHyperlink parses the data of an OSC 8, which is of the form
+// "params;URI", where params is a ':' separated list of "key=value" pairs
+func parseHyperlink(data []byte) (Hyperlink, bool) {
+	i := bytes.IndexByte(data, ';')
+	if i < 0 {
+		return Hyperlink{}, false
+	}
+	link := Hyperlink{URI: string(data[i+1:])}
+	for _, param := range strings.Split(string(data[:i]), ":") {
+		if strings.HasPrefix(param, "id=") {
+			link.ID = param[len("id="):]
+		}
+	}
+	return link, true
+}
@@ -6,3 +6,71 @@ type Output interface {
 	Print(data []byte, style Style, pos Pos) error
 	ClearRight(pos Pos) error
 }
+
+// HyperlinkOutput is an Output that keeps track of OSC 8 hyperlinks. If the
+// Output passed to a Writer implements HyperlinkOutput, PrintHyperlink is
+// called instead of Print for data that belongs to a hyperlink.
+type HyperlinkOutput interface {
+	Output
+	// PrintHyperlink is like Print, but for data that belongs to link.
+	// Implementations may retain link, which is never modified.
+	PrintHyperlink(data []byte, style Style, link *Hyperlink, pos Pos) error
+}
+
+// FixedSizeOutput is an Output with a fixed number of lines and columns, such
+// as a Screen. A Writer keeps the cursor within the output, wraps text at the
+// last column, and scrolls rather than adding lines past the last one.
+type FixedSizeOutput interface {
+	Output
+	Size() (lines, cols int)
+}
+
+// DisplayEraser is an Output that can erase whole lines. EraseDisplay actions
+// are only supported if the Output passed to a Writer implements
+// DisplayEraser.
+type DisplayEraser interface {
+	Output
+	// ClearBelow clears all lines after line
+	ClearBelow(line int) error
+	// ClearAbove clears all lines before line
+	ClearAbove(line int) error
+	// ClearAll clears every line
+	ClearAll() error
+	// ClearScrollback clears the lines that are no longer reachable by the
+	// cursor
+	ClearScrollback() error
+}
+
+// LineEditor is an Output that can insert and delete characters and lines.
+// InsertCharacters, DeleteCharacters, InsertLines and DeleteLines actions are
+// only supported if the Output passed to a Writer implements LineEditor.
+type LineEditor interface {
+	Output
+	// InsertCharacters inserts n blank cells at pos, shifting the cells from
+	// pos onwards right
+	InsertCharacters(n int, pos Pos) error
+	// DeleteCharacters removes n cells at pos, shifting the cells after them
+	// left
+	DeleteCharacters(n int, pos Pos) error
+	// InsertLines inserts n blank lines before line
+	InsertLines(n int, line int) error
+	// DeleteLines removes n lines, starting at line
+	DeleteLines(n int, line int) error
+	// ScrollUp moves the lines from top to bottom (inclusive) up n lines.
+	// The lines that move above top are removed, and blank lines fill in at
+	// the bottom. The lines outside of top and bottom are unaffected.
+	ScrollUp(n int, top, bottom int) error
+	// ScrollDown moves the lines from top to bottom (inclusive) down n lines.
+	// The lines that move below bottom are removed, and blank lines fill in at
+	// the top. The lines outside of top and bottom are unaffected.
+	ScrollDown(n int, top, bottom int) error
+}
+
+// WrapMarker is an Output that keeps track of soft-wrapped lines. When a
+// Writer wraps text that doesn't fit on a line onto the next one, it calls
+// MarkWrapped on its Output if it implements WrapMarker.
+type WrapMarker interface {
+	Output
+	// MarkWrapped marks line as continuing onto the next line
+	MarkWrapped(line int) error
+}
@@ -18,27 +18,103 @@ func (m maybeInt) withDefault(i int) int {
 	return m.value
 }
 
+// param is a parameter to a control sequence
+type param struct {
+	maybeInt
+	// sub is set for ITU T.416 sub-parameters, which are separated from the
+	// preceding parameter by a ':' rather than a ';'
+	sub bool
+}
+
+// Limits on control sequence parameters, to bound memory usage and avoid
+// overflow on malformed input
+const (
+	maxParams     = 32
+	maxParamValue = 65535
+)
+
+// The maximum number of bytes of an unsupported escape sequence that are
+// reported in an Unknown action
+const maxUnknownSize = 256
+
 type Parser struct {
 	start int
 	pos   int
 
 	currNum maybeInt
-	nums    []maybeInt
+	currSub bool
+	nums    []param
+
+	// The state of the control sequence currently being parsed, following
+	// ECMA-48 (https://www.ecma-international.org/publications/standards/Ecma-048.htm)
+	// A control sequence is "ESC [", followed by any parameter bytes (0x30-0x3F),
+	// any intermediate bytes (0x20-0x2F) and a final byte (0x40-0x7E)
+	seqLen        int
+	private       byte
+	intermediates []byte
+	malformed     bool
+
+	// The raw bytes of the escape sequence currently being parsed, for
+	// reporting sequences that aren't supported
+	seq []byte
+
+	// The contents of the control string (e.g. an OSC) currently being parsed
+	str                  []byte
+	strKind              ControlStringKind
+	maxControlStringSize int
+	// Set if bytes of the current control string were discarded
+	strTruncated bool
+
+	// Whether C0 control characters without an action of their own are
+	// dropped rather than printed
+	dropControl bool
 
 	state stateFn
 
 	actions  []Action
 	action_i int
 
-	dangling []byte
+	dangling    []byte
+	danglingBuf [utf8.UTFMax]byte
 }
 
-func NewParser() *Parser {
-	return &Parser{
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{
 		// In most cases, this pre-allocation will be plenty
-		nums:    make([]maybeInt, 0, 8),
-		actions: make([]Action, 0, 8),
-		state:   parseBytes,
+		nums:          make([]param, 0, 8),
+		intermediates: make([]byte, 0, 2),
+		seq:           make([]byte, 0, 16),
+		actions:       make([]Action, 0, 8),
+		state:         parseBytes,
+
+		maxControlStringSize: defaultMaxControlStringSize,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+type ParserOption func(*Parser)
+
+// WithMaxControlStringSize limits the number of bytes of a control string
+// (OSC, DCS, SOS, PM or APC) that are kept. Any additional bytes are
+// consumed, but discarded. A title or hyperlink that doesn't fit is reported
+// as Unknown rather than cut short. Defaults to 4096.
+func WithMaxControlStringSize(size int) ParserOption {
+	return func(p *Parser) {
+		if size >= 0 {
+			p.maxControlStringSize = size
+		}
+	}
+}
+
+// WithoutControlCharacters drops C0 control characters (and DEL) that have no
+// action of their own, such as NUL, rather than including them in Print
+// actions.
+func WithoutControlCharacters() ParserOption {
+	return func(p *Parser) {
+		p.dropControl = true
 	}
 }
 
@@ -67,7 +143,9 @@ func (p *Parser) extractDangling(input []byte) []byte {
 	if len(p.dangling) > 0 {
 		// This can be an unfortunate allocation, but it shouldn't matter too much
 		// as dangling bytes will likely be pretty rare
-		input = append(p.dangling, input...)
+		merged := make([]byte, 0, len(p.dangling)+len(input))
+		merged = append(merged, p.dangling...)
+		input = append(merged, input...)
 	}
 	leftover := 0
 	for ; leftover < utf8.UTFMax && leftover < len(input); leftover++ {
@@ -76,10 +154,20 @@ func (p *Parser) extractDangling(input []byte) []byte {
 			break
 		}
 	}
-	p.dangling = input[len(input)-leftover:]
+	// Copy the dangling bytes, since the caller is free to reuse input
+	p.dangling = append(p.danglingBuf[:0], input[len(input)-leftover:]...)
 	return input[:len(input)-leftover]
 }
 
+// Flush returns the bytes that are being held back as a possible incomplete
+// rune, e.g. once the input has ended and they can never be completed. They
+// are then no longer held back.
+func (p *Parser) Flush() []byte {
+	dangling := append([]byte(nil), p.dangling...)
+	p.dangling = p.dangling[:0]
+	return dangling
+}
+
 func (p *Parser) ParseAll(input []byte) []Action {
 	var actions []Action
 	for {
@@ -120,6 +208,22 @@ func (p *Parser) ignore() {
 	p.start = p.pos
 }
 
+// beginEscape starts recording the raw bytes of an escape sequence
+func (p *Parser) beginEscape() {
+	p.seq = append(p.seq[:0], escapeCode)
+}
+
+func (p *Parser) record(c byte) {
+	if len(p.seq) < maxUnknownSize {
+		p.seq = append(p.seq, c)
+	}
+}
+
+// emitUnknown emits the escape sequence recorded so far as an Unknown action
+func (p *Parser) emitUnknown() {
+	p.emit(Unknown{Raw: append([]byte(nil), p.seq...)})
+}
+
 func (p *Parser) next(input []byte) (byte, bool) {
 	if p.pos >= len(input) {
 		return 0, false
@@ -133,37 +237,51 @@ func (p *Parser) backup() {
 	p.pos--
 }
 
-func (p *Parser) peek(input []byte) byte {
-	if p.pos >= len(input) {
-		return 0
-	}
-	return input[p.pos]
+// The C0 control characters that have an action of their own
+var c0Actions = [...]Action{
+	'\a': Bell{},
+	'\b': Backspace{},
+	'\t': Tab(1),
+	'\n': Linebreak{},
+	'\v': VerticalTab{},
+	'\f': FormFeed{},
+	'\r': CarriageReturn{},
+}
+
+// isControl reports whether c is a C0 control character or DEL
+func isControl(c byte) bool {
+	return c < ' ' || c == 0x7f
 }
 
 func parseBytes(p *Parser, input []byte) stateFn {
-	for {
-		switch c := p.peek(input); c {
-		case escapeCode:
-			if p.pos > p.start {
-				p.print(input)
-			}
-			p.next(input)
-			return parseEscapeSequence
-		case '\n', '\r':
-			if p.pos > p.start {
-				p.print(input)
-			}
-			p.next(input)
-			if c == '\n' {
-				p.emit(Linebreak{})
-			} else {
-				p.emit(CarriageReturn{})
-			}
-			return parseBytes
+	for p.pos < len(input) {
+		c := input[p.pos]
+		if !isControl(c) {
+			p.pos++
+			continue
 		}
-		if _, ok := p.next(input); !ok {
-			break
+		var action Action
+		if int(c) < len(c0Actions) {
+			action = c0Actions[c]
+		}
+		if c != escapeCode && action == nil && !p.dropControl {
+			p.pos++
+			continue
+		}
+		if p.pos > p.start {
+			p.print(input)
+		}
+		p.next(input)
+		switch {
+		case c == escapeCode:
+			p.beginEscape()
+			return parseEscapeSequence
+		case action != nil:
+			p.emit(action)
+		default:
+			p.ignore()
 		}
+		return parseBytes
 	}
 	if p.pos > p.start {
 		p.print(input)
@@ -172,69 +290,159 @@ func parseBytes(p *Parser, input []byte) stateFn {
 }
 
 func parseEscapeSequence(p *Parser, input []byte) stateFn {
+	next, ok := p.next(input)
+	if !ok {
+		return parseEscapeSequence
+	}
+	p.record(next)
+	switch next {
+	case '[':
+	case 'H':
+		p.emit(SetTabStop{})
+		return parseBytes
+	case 'D':
+		p.emit(Index{})
+		return parseBytes
+	case 'M':
+		p.emit(ReverseIndex{})
+		return parseBytes
+	case ']':
+		p.str = p.str[:0]
+		p.strTruncated = false
+		return parseOperatingSystemCommand
+	case 'P', 'X', '^', '_':
+		p.str = p.str[:0]
+		p.strTruncated = false
+		p.strKind = ControlStringKind(next)
+		return parseControlString
+	case '7':
+		p.emit(SaveCursorPosition{})
+		return parseBytes
+	case '8':
+		p.emit(RestoreCursorPosition{})
+		return parseBytes
+	default:
+		return p.escapeSequenceByte(next, input)
+	}
 	p.nums = p.nums[:0]
 	p.currNum = maybeInt{}
+	p.currSub = false
+	p.seqLen = 0
+	p.private = 0
+	p.intermediates = p.intermediates[:0]
+	p.malformed = false
+	return parseControlSequence
+}
+
+// parseEscapeIntermediates parses the rest of an escape sequence with
+// intermediate bytes, such as "\x1b(B", none of which are supported
+func parseEscapeIntermediates(p *Parser, input []byte) stateFn {
 	next, ok := p.next(input)
 	if !ok {
-		return parseEscapeSequence
+		return parseEscapeIntermediates
 	}
-	if next != '[' {
+	p.record(next)
+	return p.escapeSequenceByte(next, input)
+}
+
+// escapeSequenceByte handles a byte of an escape sequence that isn't
+// supported: intermediate bytes continue it, and a final byte ends it
+func (p *Parser) escapeSequenceByte(c byte, input []byte) stateFn {
+	switch {
+	case c >= 0x20 && c <= 0x2f:
+		return parseEscapeIntermediates
+	case c >= 0x30 && c <= 0x7e:
+		p.emitUnknown()
+		return parseBytes
+	default:
+		// Not allowed in an escape sequence (e.g. a control character).
+		// Abandon the sequence, and let c be handled as usual
 		p.backup()
 		p.ignore()
 		return parseBytes
 	}
-	return parseControlSequence
 }
 
 func parseControlSequence(p *Parser, input []byte) stateFn {
-	var ok bool
 	for {
-		var d byte
-		d, ok = p.next(input)
+		c, ok := p.next(input)
 		if !ok {
 			return parseControlSequence
 		}
-		if !isDigit(d) {
-			break
+		p.seqLen++
+		p.record(c)
+		switch {
+		case isDigit(c):
+			if len(p.intermediates) > 0 {
+				p.malformed = true
+			} else {
+				p.currNum.value = 10*p.currNum.value + int(c-'0')
+				if p.currNum.value > maxParamValue {
+					p.currNum.value = maxParamValue
+				}
+			}
+			p.currNum.valid = true
+		case c == ';' || c == ':':
+			if len(p.intermediates) > 0 {
+				p.malformed = true
+			}
+			p.endParam()
+			p.currSub = c == ':'
+		case c >= '<' && c <= '?':
+			// Private parameter bytes are only meaningful as the first byte,
+			// e.g. "\x1b[?25l"
+			if p.seqLen == 1 {
+				p.private = c
+			} else {
+				p.malformed = true
+			}
+		case c >= 0x20 && c <= 0x2f:
+			if len(p.intermediates) < cap(p.intermediates) {
+				p.intermediates = append(p.intermediates, c)
+			} else {
+				p.malformed = true
+			}
+		case c >= 0x40 && c <= 0x7e:
+			p.endParam()
+			if p.private == '?' && len(p.intermediates) == 0 && !p.malformed {
+				return p.dispatchPrivateControlSequence(c)
+			}
+			if p.private != 0 || len(p.intermediates) > 0 || p.malformed {
+				// Well-formed, but not supported
+				p.emitUnknown()
+				return parseBytes
+			}
+			return p.dispatchControlSequence(c)
+		default:
+			// Not allowed in a control sequence (e.g. a control character).
+			// Abandon the sequence, and let c be handled as usual
+			p.backup()
+			p.ignore()
+			return parseBytes
 		}
-		p.currNum.value = 10*p.currNum.value + (int(d) - '0')
-		p.currNum.valid = true
 	}
-	p.nums = append(p.nums, p.currNum)
-	p.currNum = maybeInt{}
-
-	p.backup()
-	return parseControlSequenceMode
 }
 
-func parseControlSequenceMode(p *Parser, input []byte) stateFn {
-	mode, nextOK := p.next(input)
-	if !nextOK {
-		return parseControlSequence
+// endParam adds the current parameter to the list of parameters
+func (p *Parser) endParam() {
+	if len(p.nums) < maxParams {
+		p.nums = append(p.nums, param{maybeInt: p.currNum, sub: p.currSub})
 	}
+	p.currNum = maybeInt{}
+	p.currSub = false
+}
+
+// dispatchControlSequence emits the actions for a control sequence with the
+// given final byte
+func (p *Parser) dispatchControlSequence(final byte) stateFn {
 	var num maybeInt
 	if len(p.nums) > 0 {
-		num = p.nums[len(p.nums)-1]
+		num = p.nums[len(p.nums)-1].maybeInt
 	}
-	switch mode {
+	switch final {
 	case 'm':
-		anyOk := false
-		for i := 0; i < len(p.nums); i++ {
-			// If the final parameter is not specified, and it's not the first, don't reset
-			// e.g. "\x1b[m" and "\x1b[1;0m" reset, but "\x1b[1;m" sets to bold only (no reset)
-			// Not sure where this is in the spec, but it's how iTerm handles it
-			if i != 0 && i == len(p.nums)-1 && !p.nums[i].valid {
-				break
-			}
-			action, ok := sgrLookup(p.nums[i].withDefault(0))
-			if ok {
-				p.emit(action)
-				anyOk = true
-			}
-		}
-		if !anyOk {
-			p.ignore()
-			return parseBytes
+		if !p.parseSGR() {
+			p.emitUnknown()
 		}
 	case 'A':
 		p.emit(CursorUp(num.withDefault(1)))
@@ -261,10 +469,10 @@ func parseControlSequenceMode(p *Parser, input []byte) stateFn {
 			secondNum maybeInt
 		)
 		if len(p.nums) > 0 {
-			firstNum = p.nums[0]
+			firstNum = p.nums[0].maybeInt
 		}
 		if len(p.nums) > 1 {
-			secondNum = p.nums[1]
+			secondNum = p.nums[1].maybeInt
 		}
 		p.emit(CursorPosition(Pos{
 			Line: firstNum.withDefault(1),
@@ -275,19 +483,101 @@ func parseControlSequenceMode(p *Parser, input []byte) stateFn {
 	case 'u':
 		p.emit(RestoreCursorPosition{})
 	case 'J':
-		p.emit(EraseDisplay(num.withDefault(0)))
+		if mode := num.withDefault(0); mode <= int(EraseScrollback) {
+			p.emit(EraseDisplay(mode))
+		} else {
+			p.emitUnknown()
+		}
 	case 'K':
-		p.emit(EraseLine(num.withDefault(0)))
-	case ';':
-		return parseControlSequence
+		if mode := num.withDefault(0); mode <= int(EraseAll) {
+			p.emit(EraseLine(mode))
+		} else {
+			p.emitUnknown()
+		}
+	case 'r':
+		var region ScrollRegion
+		if len(p.nums) > 0 {
+			region.Top = p.nums[0].withDefault(0)
+		}
+		if len(p.nums) > 1 {
+			region.Bottom = p.nums[1].withDefault(0)
+		}
+		p.emit(SetScrollRegion(region))
+	case 'S':
+		p.emit(ScrollUp(num.withDefault(1)))
+	case 'T':
+		p.emit(ScrollDown(num.withDefault(1)))
+	case '@':
+		p.emit(InsertCharacters(num.withDefault(1)))
+	case 'P':
+		p.emit(DeleteCharacters(num.withDefault(1)))
+	case 'X':
+		p.emit(EraseCharacters(num.withDefault(1)))
+	case 'L':
+		p.emit(InsertLines(num.withDefault(1)))
+	case 'M':
+		p.emit(DeleteLines(num.withDefault(1)))
+	case 'h', 'l':
+		p.dispatchModes(final == 'h', 0)
+	case 'I':
+		p.emit(Tab(num.withDefault(1)))
+	case 'Z':
+		p.emit(BackTab(num.withDefault(1)))
+	case 'g':
+		switch num.withDefault(0) {
+		case 0:
+			p.emit(ClearTabStop{})
+		case 3:
+			p.emit(ClearAllTabStops{})
+		default:
+			p.emitUnknown()
+		}
 	default:
-		p.ignore()
-		return parseBytes
+		p.emitUnknown()
 	}
 
 	return parseBytes
 }
 
+// dispatchPrivateControlSequence emits the actions for a DEC private control
+// sequence (one starting with "\x1b[?") with the given final byte
+func (p *Parser) dispatchPrivateControlSequence(final byte) stateFn {
+	if final != 'h' && final != 'l' {
+		p.emitUnknown()
+		return parseBytes
+	}
+	p.dispatchModes(final == 'h', PrivateMode)
+	return parseBytes
+}
+
+// dispatchModes emits an action to set or reset each of the modes given as
+// parameters
+func (p *Parser) dispatchModes(set bool, flags Mode) {
+	if len(p.nums) == 0 {
+		p.emitUnknown()
+		return
+	}
+	for _, param := range p.nums {
+		if !param.valid || param.sub {
+			p.emitUnknown()
+			return
+		}
+	}
+	for _, param := range p.nums {
+		mode := flags | Mode(param.value)
+		switch {
+		case mode.isAlternateScreen() && set:
+			p.emit(EnterAlternateScreen(param.value))
+		case mode.isAlternateScreen():
+			p.emit(ExitAlternateScreen(param.value))
+		case set:
+			p.emit(SetMode(mode))
+		default:
+			p.emit(ResetMode(mode))
+		}
+	}
+}
+
 func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
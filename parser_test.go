@@ -1,6 +1,7 @@
 package ansi_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/aoldershaw/ansi"
@@ -56,6 +57,70 @@ func TestParser_Actions(t *testing.T) {
 				ansi.Print("bright green bg"),
 			},
 		},
+		{
+			description: "256 colours",
+			input:       []byte("\x1b[38;5;208mfg\x1b[48;5;0mbg\x1b[1;38;5;255;48;5;16;4mboth"),
+			actions: []ansi.Action{
+				ansi.SetForeground(ansi.Indexed(208)),
+				ansi.Print("fg"),
+				ansi.SetBackground(ansi.Indexed(0)),
+				ansi.Print("bg"),
+				ansi.SetBold(true),
+				ansi.SetForeground(ansi.Indexed(255)),
+				ansi.SetBackground(ansi.Indexed(16)),
+				ansi.SetUnderline(true),
+				ansi.Print("both"),
+			},
+		},
+		{
+			description: "invalid 256 colours do not leak parameters",
+			input:       []byte("\x1b[38;5;256;1m\x1b[38;5mnothing"),
+			actions: []ansi.Action{
+				ansi.SetBold(true),
+				ansi.Unknown{Raw: []byte("\x1b[38;5;256;1m")},
+				ansi.Unknown{Raw: []byte("\x1b[38;5m")},
+				ansi.Print("nothing"),
+			},
+		},
+		{
+			description: "truecolour",
+			input:       []byte("\x1b[38;2;255;136;0mfg\x1b[48;2;1;2;3;4mbg\x1b[1;38;2;0;0;0mboth"),
+			actions: []ansi.Action{
+				ansi.SetForeground(ansi.RGB(255, 136, 0)),
+				ansi.Print("fg"),
+				ansi.SetBackground(ansi.RGB(1, 2, 3)),
+				ansi.SetUnderline(true),
+				ansi.Print("bg"),
+				ansi.SetBold(true),
+				ansi.SetForeground(ansi.RGB(0, 0, 0)),
+				ansi.Print("both"),
+			},
+		},
+		{
+			description: "extended colours with colon separated sub-parameters",
+			input:       []byte("\x1b[38:2:255:136:0m\x1b[48:2::1:2:3m\x1b[38:5:208;1m\x1b[48:2:0:0:0:1;4mtext"),
+			actions: []ansi.Action{
+				ansi.SetForeground(ansi.RGB(255, 136, 0)),
+				ansi.SetBackground(ansi.RGB(1, 2, 3)),
+				ansi.SetForeground(ansi.Indexed(208)),
+				ansi.SetBold(true),
+				ansi.SetBackground(ansi.RGB(0, 0, 1)),
+				ansi.SetUnderline(true),
+				ansi.Print("text"),
+			},
+		},
+		{
+			description: "invalid truecolour does not leak parameters",
+			input:       []byte("\x1b[38;2;256;0;0;1m\x1b[38:2:1:2;4m\x1b[48;2;1;2mtext"),
+			actions: []ansi.Action{
+				ansi.SetBold(true),
+				ansi.Unknown{Raw: []byte("\x1b[38;2;256;0;0;1m")},
+				ansi.SetUnderline(true),
+				ansi.Unknown{Raw: []byte("\x1b[38:2:1:2;4m")},
+				ansi.Unknown{Raw: []byte("\x1b[48;2;1;2m")},
+				ansi.Print("text"),
+			},
+		},
 		{
 			description: "resetting",
 			input:       []byte("some text\x1b[0mreset\x1b[mreset again\x1b[;31mreset to red"),
@@ -91,6 +156,102 @@ func TestParser_Actions(t *testing.T) {
 				ansi.Print("fraktur"),
 			},
 		},
+		{
+			description: "resetting text styling",
+			input:       []byte("\x1b[22mnormal\x1b[23mnot italic\x1b[24mnot underlined\x1b[25mnot blinking\x1b[27mnot inverted"),
+			actions: []ansi.Action{
+				ansi.SetBold(false),
+				ansi.SetFaint(false),
+				ansi.Print("normal"),
+				ansi.SetItalic(false),
+				ansi.SetFraktur(false),
+				ansi.Print("not italic"),
+				ansi.SetUnderline(false),
+				ansi.Print("not underlined"),
+				ansi.SetBlink(false),
+				ansi.SetRapidBlink(false),
+				ansi.Print("not blinking"),
+				ansi.SetInverted(false),
+				ansi.Print("not inverted"),
+			},
+		},
+		{
+			description: "less common text styling",
+			input:       []byte("\x1b[6mrapid blink\x1b[8mconceal\x1b[9mstrikethrough\x1b[21mdouble underline\x1b[51mframed\x1b[53moverline"),
+			actions: []ansi.Action{
+				ansi.SetRapidBlink(true),
+				ansi.Print("rapid blink"),
+				ansi.SetConceal(true),
+				ansi.Print("conceal"),
+				ansi.SetStrikethrough(true),
+				ansi.Print("strikethrough"),
+				ansi.SetUnderlineStyle(ansi.DoubleUnderline),
+				ansi.Print("double underline"),
+				ansi.SetFramed(true),
+				ansi.Print("framed"),
+				ansi.SetOverline(true),
+				ansi.Print("overline"),
+			},
+		},
+		{
+			description: "underline styles",
+			input:       []byte("\x1b[4:0mnone\x1b[4:1mstraight\x1b[4:2mdouble\x1b[4:3mcurly\x1b[4:4mdotted\x1b[4:5mdashed\x1b[4:6;1mbold"),
+			actions: []ansi.Action{
+				ansi.SetUnderline(false),
+				ansi.Print("none"),
+				ansi.SetUnderline(true),
+				ansi.Print("straight"),
+				ansi.SetUnderlineStyle(ansi.DoubleUnderline),
+				ansi.Print("double"),
+				ansi.SetUnderlineStyle(ansi.CurlyUnderline),
+				ansi.Print("curly"),
+				ansi.SetUnderlineStyle(ansi.DottedUnderline),
+				ansi.Print("dotted"),
+				ansi.SetUnderlineStyle(ansi.DashedUnderline),
+				ansi.Print("dashed"),
+				ansi.SetBold(true),
+				ansi.Unknown{Raw: []byte("\x1b[4:6;1m")},
+				ansi.Print("bold"),
+			},
+		},
+		{
+			description: "underline colours",
+			input:       []byte("\x1b[58;2;255;0;0mrgb\x1b[58:5:9mindexed\x1b[59mdefault"),
+			actions: []ansi.Action{
+				ansi.SetUnderlineColor(ansi.RGB(255, 0, 0)),
+				ansi.Print("rgb"),
+				ansi.SetUnderlineColor(ansi.Indexed(9)),
+				ansi.Print("indexed"),
+				ansi.SetUnderlineColor(ansi.DefaultColor),
+				ansi.Print("default"),
+			},
+		},
+		{
+			description: "resetting less common text styling",
+			input:       []byte("\x1b[28mreveal\x1b[29mnot strikethrough\x1b[54mnot framed\x1b[55mnot overline"),
+			actions: []ansi.Action{
+				ansi.SetConceal(false),
+				ansi.Print("reveal"),
+				ansi.SetStrikethrough(false),
+				ansi.Print("not strikethrough"),
+				ansi.SetFramed(false),
+				ansi.Print("not framed"),
+				ansi.SetOverline(false),
+				ansi.Print("not overline"),
+			},
+		},
+		{
+			description: "resetting colours",
+			input:       []byte("\x1b[31;42;39mdefault fg\x1b[49mdefault bg"),
+			actions: []ansi.Action{
+				ansi.SetForeground(ansi.Red),
+				ansi.SetBackground(ansi.Green),
+				ansi.SetForeground(ansi.DefaultColor),
+				ansi.Print("default fg"),
+				ansi.SetBackground(ansi.DefaultColor),
+				ansi.Print("default bg"),
+			},
+		},
 		{
 			description: "multiple arguments to formatting",
 			input:       []byte("\x1b[1;31;20mhello\x1b[;46m"),
@@ -108,13 +269,15 @@ func TestParser_Actions(t *testing.T) {
 			input:       []byte("\x1b[1;69mhello"),
 			actions: []ansi.Action{
 				ansi.SetBold(true),
+				ansi.Unknown{Raw: []byte("\x1b[1;69m")},
 				ansi.Print("hello"),
 			},
 		},
 		{
-			description: "multiple arguments to formatting, all invalid, not OK",
+			description: "multiple arguments to formatting, all invalid, unknown",
 			input:       []byte("\x1b[68;69mhello"),
 			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[68;69m")},
 				ansi.Print("hello"),
 			},
 		},
@@ -187,12 +350,13 @@ func TestParser_Actions(t *testing.T) {
 		},
 		{
 			description: "erasure",
-			input:       []byte("\x1b[J\x1b[0J\x1b[1J\x1b[2J\x1b[K\x1b[0K\x1b[1K\x1b[2K"),
+			input:       []byte("\x1b[J\x1b[0J\x1b[1J\x1b[2J\x1b[3J\x1b[K\x1b[0K\x1b[1K\x1b[2K"),
 			actions: []ansi.Action{
 				ansi.EraseDisplay(ansi.EraseToEnd),
 				ansi.EraseDisplay(ansi.EraseToEnd),
 				ansi.EraseDisplay(ansi.EraseToBeginning),
 				ansi.EraseDisplay(ansi.EraseAll),
+				ansi.EraseDisplay(ansi.EraseScrollback),
 				ansi.EraseLine(ansi.EraseToEnd),
 				ansi.EraseLine(ansi.EraseToEnd),
 				ansi.EraseLine(ansi.EraseToBeginning),
@@ -200,11 +364,108 @@ func TestParser_Actions(t *testing.T) {
 			},
 		},
 		{
-			description: "incomplete escape sequence (no bracket)",
+			description: "inserting and deleting",
+			input:       []byte("\x1b[@\x1b[2@\x1b[P\x1b[3P\x1b[X\x1b[4X\x1b[L\x1b[5L\x1b[M\x1b[6M"),
+			actions: []ansi.Action{
+				ansi.InsertCharacters(1),
+				ansi.InsertCharacters(2),
+				ansi.DeleteCharacters(1),
+				ansi.DeleteCharacters(3),
+				ansi.EraseCharacters(1),
+				ansi.EraseCharacters(4),
+				ansi.InsertLines(1),
+				ansi.InsertLines(5),
+				ansi.DeleteLines(1),
+				ansi.DeleteLines(6),
+			},
+		},
+		{
+			description: "scrolling",
+			input:       []byte("\x1b[2;10r\x1b[r\x1b[;5r\x1b[S\x1b[3S\x1b[T\x1b[2T\x1bD\x1bM"),
+			actions: []ansi.Action{
+				ansi.SetScrollRegion{Top: 2, Bottom: 10},
+				ansi.SetScrollRegion{},
+				ansi.SetScrollRegion{Bottom: 5},
+				ansi.ScrollUp(1),
+				ansi.ScrollUp(3),
+				ansi.ScrollDown(1),
+				ansi.ScrollDown(2),
+				ansi.Index{},
+				ansi.ReverseIndex{},
+			},
+		},
+		{
+			description: "modes",
+			input:       []byte("\x1b[4h\x1b[20l\x1b[?7l\x1b[?6;25h\x1b[?2004h\x1b[?1000l\x1b[h\x1b[?h\x1b[?;1h\x1b[?1:2h"),
+			actions: []ansi.Action{
+				ansi.SetMode(ansi.InsertMode),
+				ansi.ResetMode(ansi.NewlineMode),
+				ansi.ResetMode(ansi.AutoWrapMode),
+				ansi.SetMode(ansi.OriginMode),
+				ansi.SetMode(ansi.CursorVisibleMode),
+				ansi.SetMode(ansi.BracketedPasteMode),
+				ansi.ResetMode(ansi.PrivateMode | 1000),
+				ansi.Unknown{Raw: []byte("\x1b[h")},
+				ansi.Unknown{Raw: []byte("\x1b[?h")},
+				ansi.Unknown{Raw: []byte("\x1b[?;1h")},
+				ansi.Unknown{Raw: []byte("\x1b[?1:2h")},
+			},
+		},
+		{
+			description: "alternate screen",
+			input:       []byte("\x1b[?1049h\x1b[?1049l\x1b[?1047h\x1b[?1047l\x1b[?47h\x1b[?47l\x1b[?1049;25h\x1b[?1049p"),
+			actions: []ansi.Action{
+				ansi.EnterAlternateScreen(ansi.AlternateScreenSaveCursor),
+				ansi.ExitAlternateScreen(ansi.AlternateScreenSaveCursor),
+				ansi.EnterAlternateScreen(ansi.AlternateScreenClearOnExit),
+				ansi.ExitAlternateScreen(ansi.AlternateScreenClearOnExit),
+				ansi.EnterAlternateScreen(ansi.AlternateScreen),
+				ansi.ExitAlternateScreen(ansi.AlternateScreen),
+				ansi.EnterAlternateScreen(ansi.AlternateScreenSaveCursor),
+				ansi.SetMode(ansi.CursorVisibleMode),
+				ansi.Unknown{Raw: []byte("\x1b[?1049p")},
+			},
+		},
+		{
+			description: "invalid erase modes",
+			input:       []byte("\x1b[4J\x1b[3K"),
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[4J")},
+				ansi.Unknown{Raw: []byte("\x1b[3K")},
+			},
+		},
+		{
+			description: "escape sequence without a bracket",
 			input:       []byte("hello\x1bworld"),
 			actions: []ansi.Action{
 				ansi.Print("hello"),
-				ansi.Print("world"),
+				ansi.Unknown{Raw: []byte("\x1bw")},
+				ansi.Print("orld"),
+			},
+		},
+		{
+			description: "two byte escape sequences",
+			input:       []byte("\x1b7saved\x1b8\x1b(Bcharset\x1b=\x1b#8\x1bc"),
+			actions: []ansi.Action{
+				ansi.SaveCursorPosition{},
+				ansi.Print("saved"),
+				ansi.RestoreCursorPosition{},
+				ansi.Unknown{Raw: []byte("\x1b(B")},
+				ansi.Print("charset"),
+				ansi.Unknown{Raw: []byte("\x1b=")},
+				ansi.Unknown{Raw: []byte("\x1b#8")},
+				ansi.Unknown{Raw: []byte("\x1bc")},
+			},
+		},
+		{
+			description: "escape sequence interrupted by a control character",
+			input:       []byte("a\x1b(\nb\x1b\rc"),
+			actions: []ansi.Action{
+				ansi.Print("a"),
+				ansi.Linebreak{},
+				ansi.Print("b"),
+				ansi.CarriageReturn{},
+				ansi.Print("c"),
 			},
 		},
 		{
@@ -212,6 +473,7 @@ func TestParser_Actions(t *testing.T) {
 			input:       []byte("hello\x1b[[world"),
 			actions: []ansi.Action{
 				ansi.Print("hello"),
+				ansi.Unknown{Raw: []byte("\x1b[[")},
 				ansi.Print("world"),
 			},
 		},
@@ -224,9 +486,181 @@ func TestParser_Actions(t *testing.T) {
 		},
 		{
 			description: "unknown escape sequence",
-			input:       []byte("hello\x1b[1Zworld"),
+			input:       []byte("hello\x1b[1yworld"),
 			actions: []ansi.Action{
 				ansi.Print("hello"),
+				ansi.Unknown{Raw: []byte("\x1b[1y")},
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "tabs",
+			input:       []byte("a\tb\x1bH\x1b[I\x1b[3I\x1b[Z\x1b[2Z\x1b[g\x1b[0g\x1b[3g\x1b[2g"),
+			actions: []ansi.Action{
+				ansi.Print("a"),
+				ansi.Tab(1),
+				ansi.Print("b"),
+				ansi.SetTabStop{},
+				ansi.Tab(1),
+				ansi.Tab(3),
+				ansi.BackTab(1),
+				ansi.BackTab(2),
+				ansi.ClearTabStop{},
+				ansi.ClearTabStop{},
+				ansi.ClearAllTabStops{},
+				ansi.Unknown{Raw: []byte("\x1b[2g")},
+			},
+		},
+		{
+			description: "C0 control characters",
+			input:       []byte("50%\b\b\b60%\a\vpage\fnull\x00\x7f"),
+			actions: []ansi.Action{
+				ansi.Print("50%"),
+				ansi.Backspace{},
+				ansi.Backspace{},
+				ansi.Backspace{},
+				ansi.Print("60%"),
+				ansi.Bell{},
+				ansi.VerticalTab{},
+				ansi.Print("page"),
+				ansi.FormFeed{},
+				ansi.Print("null\x00\x7f"),
+			},
+		},
+		{
+			description: "private control sequences are consumed",
+			input:       []byte("\x1b[?6nhidden\x1b[?u\x1b[>calt\x1b[=1;2c\x1b[<0;1;2M"),
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[?6n")},
+				ansi.Print("hidden"),
+				ansi.Unknown{Raw: []byte("\x1b[?u")},
+				ansi.Unknown{Raw: []byte("\x1b[>c")},
+				ansi.Print("alt"),
+				ansi.Unknown{Raw: []byte("\x1b[=1;2c")},
+				ansi.Unknown{Raw: []byte("\x1b[<0;1;2M")},
+			},
+		},
+		{
+			description: "control sequences with intermediate bytes are consumed",
+			input:       []byte("\x1b[ qcursor\x1b[2 q\x1b[?1$pmode\x1b[!preset"),
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[ q")},
+				ansi.Print("cursor"),
+				ansi.Unknown{Raw: []byte("\x1b[2 q")},
+				ansi.Unknown{Raw: []byte("\x1b[?1$p")},
+				ansi.Print("mode"),
+				ansi.Unknown{Raw: []byte("\x1b[!p")},
+				ansi.Print("reset"),
+			},
+		},
+		{
+			description: "malformed control sequences are consumed",
+			input:       []byte("\x1b[1?2mmisplaced private\x1b[ 1mparameter after intermediate"),
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b[1?2m")},
+				ansi.Print("misplaced private"),
+				ansi.Unknown{Raw: []byte("\x1b[ 1m")},
+				ansi.Print("parameter after intermediate"),
+			},
+		},
+		{
+			description: "control characters abort a control sequence",
+			input:       []byte("\x1b[1\nhello\x1b[31\x1b[32mgreen"),
+			actions: []ansi.Action{
+				ansi.Linebreak{},
+				ansi.Print("hello"),
+				ansi.SetForeground(ansi.Green),
+				ansi.Print("green"),
+			},
+		},
+		{
+			description: "huge parameters do not overflow",
+			input:       []byte("\x1b[99999999999999999999999999999Ahello"),
+			actions: []ansi.Action{
+				ansi.CursorUp(65535),
+				ansi.Print("hello"),
+			},
+		},
+		{
+			description: "window title",
+			input:       []byte("\x1b]0;my title\x07hello\x1b]2;other title\x1b\\world\x1b]2;\x07"),
+			actions: []ansi.Action{
+				ansi.SetTitle("my title"),
+				ansi.Print("hello"),
+				ansi.SetTitle("other title"),
+				ansi.Print("world"),
+				ansi.SetTitle(""),
+			},
+		},
+		{
+			description: "hyperlinks",
+			input:       []byte("\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\ \x1b]8;foo=bar:id=abc;file:///tmp\x07"),
+			actions: []ansi.Action{
+				ansi.SetHyperlink(ansi.Hyperlink{URI: "https://example.com"}),
+				ansi.Print("link"),
+				ansi.SetHyperlink(ansi.Hyperlink{}),
+				ansi.Print(" "),
+				ansi.SetHyperlink(ansi.Hyperlink{ID: "abc", URI: "file:///tmp"}),
+			},
+		},
+		{
+			description: "other operating system commands",
+			input:       []byte("\x1b]1;icon\x07\x1b]52;c;aGVsbG8=\x07\x1b]112\x07"),
+			actions: []ansi.Action{
+				ansi.OSC{Code: 1, Data: []byte("icon")},
+				ansi.OSC{Code: 52, Data: []byte("c;aGVsbG8=")},
+				ansi.OSC{Code: 112},
+			},
+		},
+		{
+			description: "invalid operating system commands are unknown",
+			input:       []byte("\x1b]foo;bar\x07hello\x1b]8;no uri\x1b\\world"),
+			actions: []ansi.Action{
+				ansi.Unknown{Raw: []byte("\x1b]foo;bar\x07")},
+				ansi.Print("hello"),
+				ansi.Unknown{Raw: []byte("\x1b]8;no uri\x1b\\")},
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "aborted operating system commands",
+			input:       []byte("\x1b]0;title\x18hello\x1b]0;title\x1b[1mworld"),
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.SetBold(true),
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "control strings",
+			input: []byte("a\x1bPq#0;2;0;0;0#0~~@@vv\x1b\\b\x1b_Gf=100;AAAA\x1b\\c" +
+				"\x1b^private\x1b\\d\x1bXstring\x07\x1b\\e"),
+			actions: []ansi.Action{
+				ansi.Print("a"),
+				ansi.ControlString{Kind: ansi.DeviceControlString, Data: []byte("q#0;2;0;0;0#0~~@@vv")},
+				ansi.Print("b"),
+				ansi.ControlString{Kind: ansi.ApplicationProgramCommand, Data: []byte("Gf=100;AAAA")},
+				ansi.Print("c"),
+				ansi.ControlString{Kind: ansi.PrivacyMessage, Data: []byte("private")},
+				ansi.Print("d"),
+				ansi.ControlString{Kind: ansi.StartOfString, Data: []byte("string\x07")},
+				ansi.Print("e"),
+			},
+		},
+		{
+			description: "tmux passthrough",
+			input:       []byte("\x1bPtmux;\x1b\x1b]0;title\x07\x1b\\hello"),
+			actions: []ansi.Action{
+				ansi.ControlString{Kind: ansi.DeviceControlString, Data: []byte("tmux;\x1b\x1b]0;title\x07")},
+				ansi.Print("hello"),
+			},
+		},
+		{
+			description: "aborted control strings",
+			input:       []byte("\x1bPq#0\x18hello\x1b_Gf=100\x1b[1mworld"),
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.SetBold(true),
 				ansi.Print("world"),
 			},
 		},
@@ -250,6 +684,53 @@ func TestParser_Actions(t *testing.T) {
 	}
 }
 
+func TestParser_MaxControlStringSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := ansi.NewParser(ansi.WithMaxControlStringSize(5))
+
+	actions := p.ParseAll([]byte("\x1bPtmux;\x1b\x1bPpayload\x1b\\\x1b]52;c;aGVsbG8=\x07\x1b]0;long title\x07" +
+		"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x07\x1b]2;hi\x07hello"))
+
+	g.Expect(actions).To(Equal([]ansi.Action{
+		ansi.ControlString{Kind: ansi.DeviceControlString, Data: []byte("tmux;")},
+		ansi.OSC{Code: 52, Data: []byte("c;")},
+		ansi.Unknown{Raw: []byte("\x1b]0;lon\x07")},
+		ansi.Unknown{Raw: []byte("\x1b]8;;ht\x1b\\")},
+		ansi.Print("link"),
+		ansi.SetHyperlink(ansi.Hyperlink{}),
+		ansi.SetTitle("hi"),
+		ansi.Print("hello"),
+	}))
+}
+
+func TestParser_WithoutControlCharacters(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := ansi.NewParser(ansi.WithoutControlCharacters())
+
+	actions := p.ParseAll([]byte("\x00nu\x00ll\x7f\b\x1b[1mbold\x0e\x0f\x1b]0;title\x07\x01"))
+
+	g.Expect(actions).To(Equal([]ansi.Action{
+		ansi.Print("nu"),
+		ansi.Print("ll"),
+		ansi.Backspace{},
+		ansi.SetBold(true),
+		ansi.Print("bold"),
+		ansi.SetTitle("title"),
+	}))
+}
+
+func TestParser_UnknownIsTruncated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := ansi.NewParser()
+
+	actions := p.ParseAll([]byte("\x1b[" + strings.Repeat("1;", 1000) + "yhello"))
+
+	g.Expect(actions).To(HaveLen(2))
+	g.Expect(actions[0]).To(BeAssignableToTypeOf(ansi.Unknown{}))
+	g.Expect(actions[0].(ansi.Unknown).Raw).To(HaveLen(256))
+	g.Expect(actions[1]).To(Equal(ansi.Print("hello")))
+}
+
 func TestParser_Carryover(t *testing.T) {
 	format.UseStringerRepresentation = true
 
@@ -347,6 +828,72 @@ func TestParser_Carryover(t *testing.T) {
 				ansi.Print("green and bold"),
 			},
 		},
+		{
+			description: "partial private control sequence",
+			inputs: [][]byte{
+				[]byte("hello\x1b[?2"),
+				[]byte("5"),
+				[]byte("lworld"),
+			},
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.ResetMode(ansi.CursorVisibleMode),
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "partial escape sequence with intermediate bytes",
+			inputs: [][]byte{
+				[]byte("hello\x1b"),
+				[]byte("("),
+				[]byte("Bworld"),
+			},
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.Unknown{Raw: []byte("\x1b(B")},
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "partial control sequence with intermediate bytes",
+			inputs: [][]byte{
+				[]byte("hello\x1b[2 "),
+				[]byte("qworld"),
+			},
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.Unknown{Raw: []byte("\x1b[2 q")},
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "partial control string",
+			inputs: [][]byte{
+				[]byte("hello\x1bP"),
+				[]byte("tmux;\x1b"),
+				[]byte("\x1b[1m\x1b"),
+				[]byte("\\world"),
+			},
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.ControlString{Kind: ansi.DeviceControlString, Data: []byte("tmux;\x1b\x1b[1m")},
+				ansi.Print("world"),
+			},
+		},
+		{
+			description: "partial operating system command",
+			inputs: [][]byte{
+				[]byte("hello\x1b]"),
+				[]byte("0;my "),
+				[]byte("title\x1b"),
+				[]byte("\\world"),
+			},
+			actions: []ansi.Action{
+				ansi.Print("hello"),
+				ansi.SetTitle("my title"),
+				ansi.Print("world"),
+			},
+		},
 		{
 			description: "incomplete rune",
 			inputs: [][]byte{
@@ -382,3 +929,13 @@ func TestParser_Carryover(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_Flush(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p := ansi.NewParser()
+
+	g.Expect(p.ParseAll([]byte("caf\xc3"))).To(Equal([]ansi.Action{ansi.Print("caf")}))
+	g.Expect(p.Flush()).To(Equal([]byte("\xc3")))
+	g.Expect(p.Flush()).To(BeEmpty())
+	g.Expect(p.ParseAll([]byte("e"))).To(Equal([]ansi.Action{ansi.Print("e")}))
+}
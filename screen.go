@@ -0,0 +1,357 @@
+package ansi
+
+// The default number of lines kept in a Screen's scrollback
+const defaultScrollbackSize = 1000
+
+// Screen is an Output that behaves like a terminal screen: a fixed size grid
+// of styled cells. Unlike Lines, text is wrapped at the last column, and lines
+// that scroll off the top of the screen move into a bounded scrollback.
+//
+// A Writer that writes to a Screen keeps the cursor within the screen, so
+// full-screen programs render as they would in a terminal.
+type Screen struct {
+	lines int
+	cols  int
+	grid  [][]cell
+
+	scrollback     Lines
+	scrollbackSize int
+}
+
+type cell struct {
+	// data is the grapheme cluster in the cell, which is empty if the cell is
+	// blank or the second half of a wide character
+	data  string
+	style Style
+	link  *Hyperlink
+	// wide is set on the first half of a wide character, and continuation on
+	// the second half
+	wide         bool
+	continuation bool
+}
+
+type ScreenOption func(*Screen)
+
+// WithScrollbackSize limits the number of lines kept in the scrollback.
+// Defaults to 1000.
+func WithScrollbackSize(lines int) ScreenOption {
+	return func(s *Screen) {
+		if lines >= 0 {
+			s.scrollbackSize = lines
+		}
+	}
+}
+
+func NewScreen(lines, cols int, opts ...ScreenOption) *Screen {
+	if lines <= 0 {
+		lines = defaultLines
+	}
+	if cols <= 0 {
+		cols = defaultCols
+	}
+	s := &Screen{
+		lines:          lines,
+		cols:           cols,
+		grid:           make([][]cell, lines),
+		scrollbackSize: defaultScrollbackSize,
+	}
+	for i := range s.grid {
+		s.grid[i] = make([]cell, cols)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Size returns the number of lines and columns of the screen
+func (s *Screen) Size() (int, int) {
+	return s.lines, s.cols
+}
+
+// Lines returns the visible lines of the screen, without any trailing blank
+// cells
+func (s *Screen) Lines() Lines {
+	lines := make(Lines, s.lines)
+	for i, row := range s.grid {
+		lines[i] = rowToLine(row)
+	}
+	return lines
+}
+
+// History returns the scrollback followed by the visible lines of the screen,
+// without any trailing blank lines
+func (s *Screen) History() Lines {
+	lines := make(Lines, 0, len(s.scrollback)+s.lines)
+	lines = append(lines, s.scrollback...)
+	lines = append(lines, s.Lines()...)
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func rowToLine(row []cell) Line {
+	end := len(row)
+	for end > 0 && row[end-1] == (cell{}) {
+		end--
+	}
+	line := Line{}
+	for _, c := range row[:end] {
+		if c.continuation {
+			continue
+		}
+		data := c.data
+		if data == "" {
+			data = " "
+		}
+		if len(line) > 0 && line[len(line)-1].hasFormat(c.style, c.link) {
+			last := &line[len(line)-1]
+			last.Data = append(last.Data, data...)
+			continue
+		}
+		line = append(line, Chunk{Data: Text(data), Style: c.style, Link: c.link})
+	}
+	return line
+}
+
+func (s *Screen) Print(data []byte, style Style, pos Pos) error {
+	return s.PrintHyperlink(data, style, nil, pos)
+}
+
+// PrintHyperlink prints data into the cells starting at pos. Any data that
+// doesn't fit on the line is dropped; the Writer takes care of wrapping.
+func (s *Screen) PrintHyperlink(data []byte, style Style, link *Hyperlink, pos Pos) error {
+	row := s.row(pos.Line)
+	if row == nil {
+		return nil
+	}
+	col := pos.Col
+	if col < 0 {
+		col = 0
+	}
+	for i := 0; i < len(data) && col < s.cols; {
+		n, width := graphemeAt(data, i, col)
+		switch {
+		case data[i] == '\t':
+			if col+width > s.cols {
+				width = s.cols - col
+			}
+			splitWide(row, col)
+			splitWide(row, col+width)
+			for j := col; j < col+width; j++ {
+				row[j] = cell{style: style, link: link}
+			}
+		case width == 0:
+			// A combining character on its own belongs to the preceding cell
+			if j := s.headOf(row, col-1); j >= 0 && row[j].data != "" {
+				row[j].data += string(data[i : i+n])
+			}
+		case col+width > s.cols:
+			// A wide character that doesn't fit is dropped
+			return nil
+		default:
+			splitWide(row, col)
+			splitWide(row, col+width)
+			row[col] = cell{data: string(data[i : i+n]), style: style, link: link, wide: width == 2}
+			if width == 2 {
+				row[col+1] = cell{style: style, link: link, continuation: true}
+			}
+		}
+		col += width
+		i += n
+	}
+	return nil
+}
+
+// row returns the cells of a line, or nil if the line is off the screen
+func (s *Screen) row(line int) []cell {
+	if line < 0 || line >= s.lines {
+		return nil
+	}
+	return s.grid[line]
+}
+
+// headOf returns the column of the character that occupies col, which is
+// before col for the second half of a wide character
+func (s *Screen) headOf(row []cell, col int) int {
+	if col > 0 && row[col].continuation {
+		return col - 1
+	}
+	return col
+}
+
+// splitWide blanks out a wide character that straddles the boundary before
+// col, since only half of it is about to be overwritten or moved
+func splitWide(row []cell, col int) {
+	if col > 0 && col < len(row) && row[col].continuation {
+		row[col-1] = cell{}
+		row[col] = cell{}
+	}
+}
+
+func blank(cells []cell) {
+	for i := range cells {
+		cells[i] = cell{}
+	}
+}
+
+func (s *Screen) ClearRight(pos Pos) error {
+	row := s.row(pos.Line)
+	if row == nil || pos.Col >= s.cols {
+		return nil
+	}
+	if pos.Col < 0 {
+		pos.Col = 0
+	}
+	splitWide(row, pos.Col)
+	blank(row[pos.Col:])
+	return nil
+}
+
+func (s *Screen) ClearBelow(line int) error {
+	for i := line + 1; i < s.lines; i++ {
+		if i >= 0 {
+			blank(s.grid[i])
+		}
+	}
+	return nil
+}
+
+func (s *Screen) ClearAbove(line int) error {
+	for i := 0; i < line && i < s.lines; i++ {
+		blank(s.grid[i])
+	}
+	return nil
+}
+
+func (s *Screen) ClearAll() error {
+	for _, row := range s.grid {
+		blank(row)
+	}
+	return nil
+}
+
+func (s *Screen) ClearScrollback() error {
+	s.scrollback = nil
+	return nil
+}
+
+func (s *Screen) InsertCharacters(n int, pos Pos) error {
+	row := s.row(pos.Line)
+	if row == nil || n <= 0 || pos.Col < 0 || pos.Col >= s.cols {
+		return nil
+	}
+	splitWide(row, pos.Col)
+	if pos.Col+n < s.cols {
+		copy(row[pos.Col+n:], row[pos.Col:])
+		blank(row[pos.Col : pos.Col+n])
+	} else {
+		blank(row[pos.Col:])
+	}
+	// A wide character that is pushed halfway off the line is removed
+	if last := &row[s.cols-1]; last.wide {
+		*last = cell{}
+	}
+	return nil
+}
+
+func (s *Screen) DeleteCharacters(n int, pos Pos) error {
+	row := s.row(pos.Line)
+	if row == nil || n <= 0 || pos.Col < 0 || pos.Col >= s.cols {
+		return nil
+	}
+	splitWide(row, pos.Col)
+	if pos.Col+n < s.cols {
+		splitWide(row, pos.Col+n)
+		copy(row[pos.Col:], row[pos.Col+n:])
+		blank(row[s.cols-n:])
+	} else {
+		blank(row[pos.Col:])
+	}
+	return nil
+}
+
+func (s *Screen) InsertLines(n int, line int) error {
+	s.scrollDown(n, line, s.lines-1)
+	return nil
+}
+
+func (s *Screen) DeleteLines(n int, line int) error {
+	s.scrollUp(n, line, s.lines-1, false)
+	return nil
+}
+
+// ScrollUp moves the lines from top to bottom up n lines. If top is the first
+// line of the screen, the lines that scroll off it move into the scrollback.
+func (s *Screen) ScrollUp(n int, top, bottom int) error {
+	s.scrollUp(n, top, bottom, top <= 0)
+	return nil
+}
+
+func (s *Screen) ScrollDown(n int, top, bottom int) error {
+	s.scrollDown(n, top, bottom)
+	return nil
+}
+
+func (s *Screen) scrollUp(n int, top, bottom int, save bool) {
+	rows, n := s.region(n, top, bottom)
+	if n == 0 {
+		return
+	}
+	scrolled := make([][]cell, n)
+	copy(scrolled, rows[:n])
+	if save {
+		for _, row := range scrolled {
+			s.addScrollback(rowToLine(row))
+		}
+	}
+	copy(rows, rows[n:])
+	for i, row := range scrolled {
+		blank(row)
+		rows[len(rows)-n+i] = row
+	}
+}
+
+func (s *Screen) scrollDown(n int, top, bottom int) {
+	rows, n := s.region(n, top, bottom)
+	if n == 0 {
+		return
+	}
+	scrolled := make([][]cell, n)
+	copy(scrolled, rows[len(rows)-n:])
+	copy(rows[n:], rows)
+	for i, row := range scrolled {
+		blank(row)
+		rows[i] = row
+	}
+}
+
+// region returns the rows from top to bottom, and the number of lines that
+// they can scroll by, which is at most n
+func (s *Screen) region(n int, top, bottom int) ([][]cell, int) {
+	if top < 0 {
+		top = 0
+	}
+	if bottom >= s.lines {
+		bottom = s.lines - 1
+	}
+	if n <= 0 || top > bottom {
+		return nil, 0
+	}
+	rows := s.grid[top : bottom+1]
+	if n > len(rows) {
+		n = len(rows)
+	}
+	return rows, n
+}
+
+func (s *Screen) addScrollback(line Line) {
+	if s.scrollbackSize == 0 {
+		return
+	}
+	s.scrollback = append(s.scrollback, line)
+	if len(s.scrollback) > s.scrollbackSize {
+		s.scrollback = s.scrollback[len(s.scrollback)-s.scrollbackSize:]
+	}
+}
@@ -0,0 +1,281 @@
+package ansi_test
+
+import (
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestScreen(t *testing.T) {
+	bold := ansi.Style{Modifier: ansi.Bold}
+	for _, tt := range []struct {
+		description string
+		lines       int
+		cols        int
+		opts        []ansi.ScreenOption
+		input       string
+		visible     ansi.Lines
+		history     ansi.Lines
+	}{
+		{
+			description: "text wraps at the last column",
+			lines:       3,
+			cols:        5,
+			input:       "hello world",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("hello")}},
+				{{Data: ansi.Text(" worl")}},
+				{{Data: ansi.Text("d")}},
+			},
+		},
+		{
+			description: "wrapping is deferred until the next character",
+			lines:       3,
+			cols:        5,
+			input:       "hello\r\nworld\x1b[1mx",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("hello")}},
+				{{Data: ansi.Text("world")}},
+				{{Data: ansi.Text("x"), Style: bold}},
+			},
+		},
+		{
+			description: "moving the cursor cancels wrapping",
+			lines:       2,
+			cols:        5,
+			input:       "hello\bx",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("helxo")}},
+				{},
+			},
+		},
+		{
+			description: "without auto-wrap, the last column is overwritten",
+			lines:       2,
+			cols:        5,
+			input:       "\x1b[?7lhello world\nhello world\x1b[?7h!",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("helld")}},
+				{{Data: ansi.Text("hell!")}},
+			},
+		},
+		{
+			description: "insert mode",
+			lines:       1,
+			cols:        5,
+			input:       "abc\r\x1b[4hxy",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("xyabc")}},
+			},
+		},
+		{
+			description: "wide characters that don't fit wrap",
+			lines:       2,
+			cols:        5,
+			input:       "ab世界",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("ab世")}},
+				{{Data: ansi.Text("界")}},
+			},
+		},
+		{
+			description: "overwriting half of a wide character",
+			lines:       1,
+			cols:        5,
+			input:       "世界\x1b[1;2Hx",
+			visible: ansi.Lines{
+				{{Data: ansi.Text(" x界")}},
+			},
+		},
+		{
+			description: "cursor positions count from 1",
+			lines:       3,
+			cols:        5,
+			input:       "\x1b[2;3Hx\x1b[Gy\x1b[2;3r\x1b[?6h\x1b[2;2Hz",
+			visible: ansi.Lines{
+				{},
+				{{Data: ansi.Text("y x")}},
+				{{Data: ansi.Text(" z")}},
+			},
+		},
+		{
+			description: "the cursor stays on the screen",
+			lines:       3,
+			cols:        5,
+			input:       "\x1b[10;10Hx\x1b[5Cy",
+			visible: ansi.Lines{
+				{},
+				{},
+				{{Data: ansi.Text("    y")}},
+			},
+		},
+		{
+			description: "lines scroll into the scrollback",
+			lines:       2,
+			cols:        5,
+			input:       "one\ntwo\nthree\nfour",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+			},
+			history: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+			},
+		},
+		{
+			description: "the scrollback is bounded",
+			lines:       2,
+			cols:        5,
+			opts:        []ansi.ScreenOption{ansi.WithScrollbackSize(1)},
+			input:       "one\ntwo\nthree\nfour",
+			history: ansi.Lines{
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+			},
+		},
+		{
+			description: "history excludes trailing blank lines",
+			lines:       4,
+			cols:        5,
+			input:       "one",
+			history: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+			},
+		},
+		{
+			description: "scrolling within a scroll region keeps the lines outside of it",
+			lines:       3,
+			cols:        5,
+			input:       "one\ntwo\nthree\x1b[2;3r\x1b[3;1Hfour\nfive",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("foure")}},
+				{{Data: ansi.Text("five")}},
+			},
+			history: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("foure")}},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+		{
+			description: "erasing the display",
+			lines:       2,
+			cols:        5,
+			input:       "one\ntwo\x1b[2J\x1b[Hx",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("x")}},
+				{},
+			},
+		},
+		{
+			description: "erasing the scrollback",
+			lines:       1,
+			cols:        5,
+			input:       "one\ntwo\x1b[3J",
+			history: ansi.Lines{
+				{{Data: ansi.Text("two")}},
+			},
+		},
+		{
+			description: "inserting and deleting characters",
+			lines:       2,
+			cols:        5,
+			input:       "abcde\r\x1b[2@\nabcde\r\x1b[2P",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("  abc")}},
+				{{Data: ansi.Text("cde")}},
+			},
+		},
+		{
+			description: "inserting and deleting lines",
+			lines:       3,
+			cols:        5,
+			input:       "one\ntwo\nthree\x1b[H\x1b[L\x1b[3;1H\x1b[M",
+			visible: ansi.Lines{
+				{},
+				{{Data: ansi.Text("one")}},
+				{},
+			},
+		},
+		{
+			description: "the alternate screen",
+			lines:       2,
+			cols:        5,
+			input:       "one\ntwo\x1b[?1049h\x1b[1;1Hfull\nscreen\nhere\x1b[?1049l!",
+			visible: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two!")}},
+			},
+			history: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two!")}},
+			},
+		},
+		{
+			description: "styles and erased cells",
+			lines:       1,
+			cols:        10,
+			input:       "\x1b[1mbold\x1b[m text\x1b[1;3H\x1b[2X",
+			visible: ansi.Lines{
+				{
+					{Data: ansi.Text("bo"), Style: bold},
+					{Data: ansi.Text("   text")},
+				},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			screen := ansi.NewScreen(tt.lines, tt.cols, tt.opts...)
+			writer := ansi.NewWriter(screen)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.visible != nil {
+				g.Expect(screen.Lines()).To(Equal(tt.visible))
+			}
+			if tt.history != nil {
+				g.Expect(screen.History()).To(Equal(tt.history))
+			}
+		})
+	}
+}
+
+func TestScreen_ScrollRegion(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		region      *ansi.ScrollRegion
+	}{
+		{
+			description: "the scroll region is set from 1",
+			input:       "\x1b[2;3r",
+			region:      &ansi.ScrollRegion{Top: 1, Bottom: 2},
+		},
+		{
+			description: "the scroll region ends at the bottom of the screen",
+			input:       "\x1b[2;10r",
+			region:      &ansi.ScrollRegion{Top: 1, Bottom: 2},
+		},
+		{
+			description: "a scroll region covering the screen is reset",
+			input:       "\x1b[2;3r\x1b[1;10r",
+			region:      nil,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			writer := ansi.NewWriter(ansi.NewScreen(3, 5))
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(writer.ScrollRegion).To(Equal(tt.region))
+		})
+	}
+}
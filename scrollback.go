@@ -0,0 +1,148 @@
+package ansi
+
+import "sort"
+
+// keptLines is a run of n lines of an output, from line onwards, that
+// scrolled off the top of the scroll region and were kept. They are no longer
+// on the screen, so the cursor skips over them.
+type keptLines struct {
+	line int
+	n    int
+}
+
+// outputLine returns the line of the output that is on line row of the
+// screen
+func (w *Writer) outputLine(row int) int {
+	line := row
+	for _, k := range w.kept {
+		if k.line > line {
+			break
+		}
+		line += k.n
+	}
+	return line
+}
+
+// screenRow returns the line of the screen that line of the output is on. A
+// line that was kept counts as being on the screen line after it.
+func (w *Writer) screenRow(line int) int {
+	row := line
+	for _, k := range w.kept {
+		if k.line >= line {
+			break
+		}
+		if line < k.line+k.n {
+			return row - (line - k.line)
+		}
+		row -= k.n
+	}
+	return row
+}
+
+// isKept returns whether line of the output was kept
+func (w *Writer) isKept(line int) bool {
+	for _, k := range w.kept {
+		if line < k.line {
+			return false
+		}
+		if line < k.line+k.n {
+			return true
+		}
+	}
+	return false
+}
+
+// mapLines replaces each line that the cursor, the saved cursor, the scroll
+// region and MaxLine are on with f(line). They are converted to lines of the
+// screen before the lines that were kept change, and back again afterwards.
+func (w *Writer) mapLines(f func(int) int) {
+	w.Position.Line = f(w.Position.Line)
+	if w.SavedPosition != nil {
+		pos := *w.SavedPosition
+		pos.Line = f(pos.Line)
+		w.SavedPosition = &pos
+	}
+	if r := w.ScrollRegion; r != nil {
+		w.ScrollRegion = &ScrollRegion{Top: f(r.Top), Bottom: f(r.Bottom)}
+	}
+	w.MaxLine = f(w.MaxLine)
+}
+
+// keepLines keeps the n lines at the top of the scroll region, from line top
+// to line bottom of the output, after n blank lines have been inserted below
+// it. Everything on the screen stays where it is.
+func (w *Writer) keepLines(n int, top, bottom int) {
+	topRow := w.screenRow(top)
+	w.mapLines(w.screenRow)
+
+	// Never modified in place, so that copies of a State don't share changes
+	kept := make([]keptLines, 0, len(w.kept)+1)
+	for _, k := range w.kept {
+		if k.line > bottom {
+			k.line += n
+		}
+		kept = append(kept, k)
+	}
+	w.kept = kept
+	first, last := w.outputLine(topRow), w.outputLine(topRow+n-1)
+
+	// Any lines that were already kept between first and last, or next to
+	// them, become part of the same run
+	kept = kept[:0:0]
+	for _, k := range w.kept {
+		if k.line+k.n < first || k.line > last+1 {
+			kept = append(kept, k)
+			continue
+		}
+		if k.line < first {
+			first = k.line
+		}
+		if k.line+k.n-1 > last {
+			last = k.line + k.n - 1
+		}
+	}
+	kept = append(kept, keptLines{line: first, n: last - first + 1})
+	sort.Slice(kept, func(i, j int) bool { return kept[i].line < kept[j].line })
+	w.kept = kept
+
+	w.mapLines(w.outputLine)
+}
+
+// clearKept deletes the lines that were kept from out
+func (w *Writer) clearKept(out LineEditor) error {
+	w.mapLines(w.screenRow)
+	kept := w.kept
+	w.kept = nil
+	for i := len(kept) - 1; i >= 0; i-- {
+		if err := out.DeleteLines(kept[i].n, kept[i].line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eraseScreenLines empties the lines of out from first up to (but not
+// including) end, other than the lines that were kept. If end is negative,
+// the lines from first onwards are erased.
+func (w *Writer) eraseScreenLines(out DisplayEraser, first, end int) error {
+	if end < 0 {
+		// After the last run of kept lines, the lines can simply be removed
+		last := w.kept[len(w.kept)-1]
+		end = last.line + last.n
+		if end < first {
+			end = first
+		}
+		if err := out.ClearBelow(end - 1); err != nil {
+			return err
+		}
+	}
+	for line := first; line < end; line++ {
+		if w.isKept(line) {
+			continue
+		}
+		if err := out.ClearRight(Pos{Line: line}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -11,8 +11,18 @@ var sgrParamToAction = [maxCode]Action{
 	3:  SetItalic(true),
 	4:  SetUnderline(true),
 	5:  SetBlink(true),
+	6:  SetRapidBlink(true),
 	7:  SetInverted(true),
+	8:  SetConceal(true),
+	9:  SetStrikethrough(true),
 	20: SetFraktur(true),
+	// Some terminals (e.g. the Linux console) treat this as normal intensity,
+	// but ECMA-48 and most modern terminals treat it as doubly underlined
+	21: SetUnderlineStyle(DoubleUnderline),
+	24: SetUnderline(false),
+	27: SetInverted(false),
+	28: SetConceal(false),
+	29: SetStrikethrough(false),
 
 	30: SetForeground(Black),
 	31: SetForeground(Red),
@@ -34,6 +44,14 @@ var sgrParamToAction = [maxCode]Action{
 	47: SetBackground(White),
 	49: SetBackground(DefaultColor),
 
+	51: SetFramed(true),
+	53: SetOverline(true),
+	// Also "not encircled", which is not supported
+	54: SetFramed(false),
+	55: SetOverline(false),
+
+	59: SetUnderlineColor(DefaultColor),
+
 	90: SetForeground(BrightBlack),
 	91: SetForeground(BrightRed),
 	92: SetForeground(BrightGreen),
@@ -53,6 +71,16 @@ var sgrParamToAction = [maxCode]Action{
 	107: SetBackground(BrightWhite),
 }
 
+// Parameters that reset multiple attributes at once
+var sgrParamToActions = map[int][]Action{
+	// Normal intensity: neither bold nor faint
+	22: {SetBold(false), SetFaint(false)},
+	// Neither italic nor fraktur
+	23: {SetItalic(false), SetFraktur(false)},
+	// Neither slowly nor rapidly blinking
+	25: {SetBlink(false), SetRapidBlink(false)},
+}
+
 func sgrLookup(code int) (Action, bool) {
 	if code >= maxCode || code < 0 {
 		return nil, false
@@ -63,3 +91,172 @@ func sgrLookup(code int) (Action, bool) {
 	}
 	return act, true
 }
+
+// Parameters that introduce an extended color, consuming the parameters that
+// follow them
+const (
+	sgrExtendedForeground = 38
+	sgrExtendedBackground = 48
+	sgrUnderlineColor     = 58
+)
+
+// The parameter whose sub-parameter selects an UnderlineStyle, e.g. "4:3"
+const sgrUnderline = 4
+
+// Sub-parameters of sgrUnderline, e.g. "\x1b[4:3m" is a curly underline
+var sgrUnderlineSubParamToAction = [...]Action{
+	0: SetUnderline(false),
+	1: SetUnderline(true),
+	2: SetUnderlineStyle(DoubleUnderline),
+	3: SetUnderlineStyle(CurlyUnderline),
+	4: SetUnderlineStyle(DottedUnderline),
+	5: SetUnderlineStyle(DashedUnderline),
+}
+
+// Color spaces for an extended color
+const (
+	extendedColorRGB     = 2
+	extendedColorIndexed = 5
+)
+
+// parseSGR emits the actions for the parameters of a Select Graphic Rendition
+// sequence, returning whether all of the parameters are supported.
+func (p *Parser) parseSGR() bool {
+	allOk := true
+	for i := 0; i < len(p.nums); i++ {
+		// If the final parameter is not specified, and it's not the first, don't reset
+		// e.g. "\x1b[m" and "\x1b[1;0m" reset, but "\x1b[1;m" sets to bold only (no reset)
+		// Not sure where this is in the spec, but it's how iTerm handles it
+		if i != 0 && i == len(p.nums)-1 && !p.nums[i].valid {
+			break
+		}
+		code := p.nums[i].withDefault(0)
+		subs := subParams(p.nums[i+1:])
+		i += len(subs)
+
+		switch {
+		case code == sgrUnderline && len(subs) > 0:
+			style := subs[0].withDefault(0)
+			if style >= 0 && style < len(sgrUnderlineSubParamToAction) {
+				p.emit(sgrUnderlineSubParamToAction[style])
+			} else {
+				allOk = false
+			}
+		case code == sgrExtendedForeground || code == sgrExtendedBackground || code == sgrUnderlineColor:
+			var (
+				color Color
+				ok    bool
+			)
+			if len(subs) > 0 {
+				color, ok = parseExtendedColorSubParams(subs)
+			} else {
+				var consumed int
+				color, consumed, ok = parseExtendedColor(p.nums[i+1:])
+				i += consumed
+			}
+			if ok {
+				p.emit(extendedColorAction(code, color))
+			} else {
+				allOk = false
+			}
+		default:
+			if actions, ok := sgrParamToActions[code]; ok {
+				for _, action := range actions {
+					p.emit(action)
+				}
+			} else if action, ok := sgrLookup(code); ok {
+				p.emit(action)
+			} else {
+				allOk = false
+			}
+		}
+	}
+	return allOk
+}
+
+func extendedColorAction(code int, color Color) Action {
+	switch code {
+	case sgrExtendedForeground:
+		return SetForeground(color)
+	case sgrExtendedBackground:
+		return SetBackground(color)
+	default:
+		return SetUnderlineColor(color)
+	}
+}
+
+// subParams returns the leading sub-parameters of nums
+func subParams(nums []param) []param {
+	n := 0
+	for n < len(nums) && nums[n].sub {
+		n++
+	}
+	return nums[:n]
+}
+
+// parseExtendedColor parses the ';' separated parameters following a 38 or 48,
+// returning the color and the number of parameters that belong to it.
+func parseExtendedColor(nums []param) (Color, int, bool) {
+	if len(nums) == 0 {
+		return 0, 0, false
+	}
+	switch nums[0].withDefault(0) {
+	case extendedColorIndexed:
+		if len(nums) < 2 {
+			return 0, len(nums), false
+		}
+		color, ok := indexedColor(nums[1])
+		return color, 2, ok
+	case extendedColorRGB:
+		if len(nums) < 4 {
+			return 0, len(nums), false
+		}
+		color, ok := rgbColor(nums[1:4])
+		return color, 4, ok
+	}
+	return 0, 1, false
+}
+
+// parseExtendedColorSubParams parses the ':' separated sub-parameters of a 38
+// or 48. Both the ITU T.416 form, which includes a color space ID (e.g.
+// "38:2::255:128:0"), and the more common form without it (e.g.
+// "38:2:255:128:0") are accepted.
+func parseExtendedColorSubParams(subs []param) (Color, bool) {
+	switch subs[0].withDefault(0) {
+	case extendedColorIndexed:
+		if len(subs) != 2 {
+			return 0, false
+		}
+		return indexedColor(subs[1])
+	case extendedColorRGB:
+		if len(subs) == 4 {
+			return rgbColor(subs[1:])
+		}
+		if len(subs) >= 5 {
+			// Skip the color space ID, and ignore any trailing tolerance
+			// parameters
+			return rgbColor(subs[2:5])
+		}
+	}
+	return 0, false
+}
+
+func indexedColor(num param) (Color, bool) {
+	i := num.withDefault(0)
+	if !validColorComponent(i) {
+		return 0, false
+	}
+	return Indexed(uint8(i)), true
+}
+
+func rgbColor(nums []param) (Color, bool) {
+	r, g, b := nums[0].withDefault(0), nums[1].withDefault(0), nums[2].withDefault(0)
+	if !validColorComponent(r) || !validColorComponent(g) || !validColorComponent(b) {
+		return 0, false
+	}
+	return RGB(uint8(r), uint8(g), uint8(b)), true
+}
+
+func validColorComponent(i int) bool {
+	return i >= 0 && i <= 255
+}
@@ -0,0 +1,135 @@
+package ansi
+
+import "io"
+
+// PlainText returns the text of the lines without any styling, with lines
+// separated by "\n". Soft-wrapped lines are joined to the next line.
+func (l Lines) PlainText() string {
+	size := 0
+	for i := range l {
+		size += l.lineLength(i) + 1
+	}
+	text := make([]byte, 0, size)
+	for i, line := range l {
+		if i > 0 && !isWrapped(l[i-1]) {
+			text = append(text, '\n')
+		}
+		for _, chunk := range line {
+			text = append(text, chunk.Data...)
+		}
+	}
+	return string(text)
+}
+
+// appendStripped appends input to dst with all escape sequences removed.
+// C0 control characters, such as linebreaks, carriage returns and tabs, are
+// kept as-is.
+func appendStripped(p *Parser, dst, input []byte) []byte {
+	for {
+		action, ok, newInput := p.Parse(input)
+		if !ok {
+			return dst
+		}
+		switch v := action.(type) {
+		case Print:
+			dst = append(dst, v...)
+		case Linebreak:
+			dst = append(dst, '\n')
+		case CarriageReturn:
+			dst = append(dst, '\r')
+		case Backspace:
+			dst = append(dst, '\b')
+		case Bell:
+			dst = append(dst, '\a')
+		case VerticalTab:
+			dst = append(dst, '\v')
+		case FormFeed:
+			dst = append(dst, '\f')
+		case Tab:
+			for i := 0; i < int(v); i++ {
+				dst = append(dst, '\t')
+			}
+		}
+		input = newInput
+	}
+}
+
+// StripWriter removes all escape sequences from the bytes written to it
+// before writing them to an underlying io.Writer. Unlike writing to Lines,
+// cursor movement and erasure are not interpreted.
+type StripWriter struct {
+	w      io.Writer
+	parser *Parser
+	buf    []byte
+}
+
+func NewStripWriter(w io.Writer) *StripWriter {
+	return &StripWriter{
+		w:      w,
+		parser: NewParser(),
+	}
+}
+
+func (s *StripWriter) Write(input []byte) (int, error) {
+	s.buf = appendStripped(s.parser, s.buf[:0], input)
+	if len(s.buf) == 0 {
+		return len(input), nil
+	}
+	if _, err := s.w.Write(s.buf); err != nil {
+		return 0, err
+	}
+	return len(input), nil
+}
+
+// Flush writes out any bytes being held back as a possible incomplete rune.
+// It should be called once everything has been written, since they will then
+// never be completed.
+func (s *StripWriter) Flush() error {
+	dangling := s.parser.Flush()
+	if len(dangling) == 0 {
+		return nil
+	}
+	_, err := s.w.Write(dangling)
+	return err
+}
+
+// StripReader removes all escape sequences from the bytes read from an
+// underlying io.Reader. Unlike writing to Lines, cursor movement and erasure
+// are not interpreted.
+type StripReader struct {
+	r      io.Reader
+	parser *Parser
+	err    error
+
+	readBuf []byte
+	// Stripped bytes that have not yet been read
+	pending []byte
+	outBuf  []byte
+}
+
+func NewStripReader(r io.Reader) *StripReader {
+	return &StripReader{
+		r:       r,
+		parser:  NewParser(),
+		readBuf: make([]byte, readBufferSize),
+	}
+}
+
+func (s *StripReader) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 && s.err == nil {
+		n, err := s.r.Read(s.readBuf)
+		s.outBuf = appendStripped(s.parser, s.outBuf[:0], s.readBuf[:n])
+		if err == io.EOF {
+			// An incomplete rune at the end will never be completed
+			s.outBuf = append(s.outBuf, s.parser.Flush()...)
+		}
+		s.pending = s.outBuf
+		s.err = err
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	if len(s.pending) == 0 {
+		return n, s.err
+	}
+	return n, nil
+}
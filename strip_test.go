@@ -0,0 +1,152 @@
+package ansi_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestLines_PlainText(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+	writer.WriteString("\x1b[1mbold\x1b[m text\nloading...\rdone!     \n\n\x1b[31mred")
+
+	g.Expect(lines.PlainText()).To(Equal("bold text\ndone!     \n\nred"))
+}
+
+func TestLines_PlainText_Wrapped(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines, ansi.WithInitialScreenSize(10, 5), ansi.WithFixedWidth())
+	writer.WriteString("hello world\nbye")
+
+	g.Expect(lines.PlainText()).To(Equal("hello world\nbye"))
+}
+
+func TestLines_PlainText_Empty(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ansi.Lines{}.PlainText()).To(Equal(""))
+}
+
+const strippable = "\x1b[1mbold\x1b[m \xe3\x81\x93\x1b[38;2;255;0;0m red\x1b[0m\r\nline\t2\x1b[2K"
+
+func TestStripWriter(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var buf bytes.Buffer
+	writer := ansi.NewStripWriter(&buf)
+
+	// Splits up runes and escape sequences
+	for i := 0; i < len(strippable); i++ {
+		n, err := writer.Write([]byte{strippable[i]})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(n).To(Equal(1))
+	}
+
+	g.Expect(buf.String()).To(Equal("bold こ red\r\nline\t2"))
+}
+
+func TestStripWriter_Error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	writer := ansi.NewStripWriter(failingWriter{})
+
+	n, err := writer.Write([]byte("\x1b[1m"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(n).To(Equal(4))
+
+	_, err = writer.Write([]byte("text"))
+	g.Expect(err).To(MatchError("failed"))
+}
+
+func TestStripWriter_Flush(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var buf bytes.Buffer
+	writer := ansi.NewStripWriter(&buf)
+
+	_, err := writer.Write([]byte("caf\xe9"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(buf.String()).To(Equal("caf"))
+
+	g.Expect(writer.Flush()).To(Succeed())
+	g.Expect(buf.String()).To(Equal("caf\xe9"))
+
+	failing := ansi.NewStripWriter(failingWriter{})
+	g.Expect(failing.Flush()).To(Succeed())
+	_, err = failing.Write([]byte("\xe9"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(failing.Flush()).To(MatchError("failed"))
+}
+
+func TestStripReader(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		reader      io.Reader
+	}{
+		{
+			description: "single read",
+			reader:      strings.NewReader(strippable),
+		},
+		{
+			description: "one byte at a time",
+			reader:      iotest.OneByteReader(strings.NewReader(strippable)),
+		},
+		{
+			description: "data returned with EOF",
+			reader:      iotest.DataErrReader(strings.NewReader(strippable)),
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			stripped, err := ioutil.ReadAll(ansi.NewStripReader(tt.reader))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(stripped)).To(Equal("bold こ red\r\nline\t2"))
+		})
+	}
+}
+
+func TestStripReader_SmallReads(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	stripped, err := ioutil.ReadAll(iotest.OneByteReader(ansi.NewStripReader(strings.NewReader(strippable))))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(stripped)).To(Equal("bold こ red\r\nline\t2"))
+}
+
+func TestStripReader_IncompleteRuneAtEOF(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	stripped, err := ioutil.ReadAll(ansi.NewStripReader(strings.NewReader("caf\xe9")))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(stripped)).To(Equal("caf\xe9"))
+
+	stripped, err = ioutil.ReadAll(ansi.NewStripReader(iotest.OneByteReader(strings.NewReader("caf\xe9"))))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(stripped)).To(Equal("caf\xe9"))
+}
+
+func TestStripReader_Error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	reader := ansi.NewStripReader(iotest.TimeoutReader(strings.NewReader("\x1b[1mbold")))
+
+	buf := make([]byte, 64)
+	n, err := reader.Read(buf)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(buf[:n])).To(Equal("bold"))
+
+	_, err = reader.Read(buf)
+	g.Expect(err).To(Equal(iotest.ErrTimeout))
+}
@@ -1,8 +1,11 @@
 package ansi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+)
 
-type StyleModifier uint8
+type StyleModifier uint16
 
 const (
 	Bold StyleModifier = 1 << iota
@@ -13,12 +16,47 @@ const (
 	Inverted
 	Fraktur
 	Framed
+	RapidBlink
+	Conceal
+	Strikethrough
+	Overline
+)
+
+// UnderlineStyle refines how underlined text (i.e. with the Underline
+// modifier) is underlined.
+type UnderlineStyle uint8
+
+const (
+	StraightUnderline UnderlineStyle = iota
+	DoubleUnderline
+	CurlyUnderline
+	DottedUnderline
+	DashedUnderline
 )
 
+var underlineStyleNames = [5]string{
+	"straight",
+	"double",
+	"curly",
+	"dotted",
+	"dashed",
+}
+
+func (u UnderlineStyle) String() string {
+	if int(u) >= len(underlineStyleNames) {
+		return ""
+	}
+	return underlineStyleNames[u]
+}
+
 type Style struct {
 	Foreground Color
 	Background Color
 	Modifier   StyleModifier
+
+	// UnderlineStyle is only meaningful if Modifier includes Underline
+	UnderlineStyle UnderlineStyle
+	UnderlineColor Color
 }
 
 func (s Style) MarshalJSON() ([]byte, error) {
@@ -28,14 +66,28 @@ func (s Style) MarshalJSON() ([]byte, error) {
 		Bold:       s.Modifier&Bold != 0,
 		Faint:      s.Modifier&Faint != 0,
 		Italic:     s.Modifier&Italic != 0,
-		Underline:  s.Modifier&Underline != 0,
+		Underline:  s.underlineJSON(),
 		Blink:      s.Modifier&Blink != 0,
 		Inverted:   s.Modifier&Inverted != 0,
 		Fraktur:    s.Modifier&Fraktur != 0,
 		Framed:     s.Modifier&Framed != 0,
+
+		RapidBlink:    s.Modifier&RapidBlink != 0,
+		Conceal:       s.Modifier&Conceal != 0,
+		Strikethrough: s.Modifier&Strikethrough != 0,
+		Overline:      s.Modifier&Overline != 0,
+
+		UnderlineColor: s.UnderlineColor,
 	})
 }
 
+func (s Style) underlineJSON() jsonUnderline {
+	if s.Modifier&Underline == 0 {
+		return ""
+	}
+	return jsonUnderline(s.UnderlineStyle.String())
+}
+
 func (s *Style) UnmarshalJSON(data []byte) error {
 	var ss style
 	if err := json.Unmarshal(data, &ss); err != nil {
@@ -43,14 +95,25 @@ func (s *Style) UnmarshalJSON(data []byte) error {
 	}
 	s.Foreground = ss.Foreground
 	s.Background = ss.Background
+	s.UnderlineColor = ss.UnderlineColor
+	s.UnderlineStyle = StraightUnderline
+	for i, name := range underlineStyleNames {
+		if string(ss.Underline) == name {
+			s.UnderlineStyle = UnderlineStyle(i)
+		}
+	}
 	s.Modifier.applyBit(ss.Bold, Bold)
 	s.Modifier.applyBit(ss.Faint, Faint)
 	s.Modifier.applyBit(ss.Italic, Italic)
-	s.Modifier.applyBit(ss.Underline, Underline)
+	s.Modifier.applyBit(ss.Underline != "", Underline)
 	s.Modifier.applyBit(ss.Blink, Blink)
 	s.Modifier.applyBit(ss.Inverted, Inverted)
 	s.Modifier.applyBit(ss.Fraktur, Fraktur)
 	s.Modifier.applyBit(ss.Framed, Framed)
+	s.Modifier.applyBit(ss.RapidBlink, RapidBlink)
+	s.Modifier.applyBit(ss.Conceal, Conceal)
+	s.Modifier.applyBit(ss.Strikethrough, Strikethrough)
+	s.Modifier.applyBit(ss.Overline, Overline)
 	return nil
 }
 
@@ -63,14 +126,52 @@ func (s *StyleModifier) applyBit(b bool, bit StyleModifier) {
 }
 
 type style struct {
-	Foreground Color `json:"fg,omitempty"`
-	Background Color `json:"bg,omitempty"`
-	Bold       bool  `json:"bold,omitempty"`
-	Faint      bool  `json:"faint,omitempty"`
-	Italic     bool  `json:"italic,omitempty"`
-	Underline  bool  `json:"underline,omitempty"`
-	Blink      bool  `json:"blink,omitempty"`
-	Inverted   bool  `json:"inverted,omitempty"`
-	Fraktur    bool  `json:"fraktur,omitempty"`
-	Framed     bool  `json:"framed,omitempty"`
+	Foreground Color         `json:"fg,omitempty"`
+	Background Color         `json:"bg,omitempty"`
+	Bold       bool          `json:"bold,omitempty"`
+	Faint      bool          `json:"faint,omitempty"`
+	Italic     bool          `json:"italic,omitempty"`
+	Underline  jsonUnderline `json:"underline,omitempty"`
+	Blink      bool          `json:"blink,omitempty"`
+	Inverted   bool          `json:"inverted,omitempty"`
+	Fraktur    bool          `json:"fraktur,omitempty"`
+	Framed     bool          `json:"framed,omitempty"`
+
+	RapidBlink    bool `json:"rapid_blink,omitempty"`
+	Conceal       bool `json:"conceal,omitempty"`
+	Strikethrough bool `json:"strikethrough,omitempty"`
+	Overline      bool `json:"overline,omitempty"`
+
+	UnderlineColor Color `json:"underline_color,omitempty"`
+}
+
+// jsonUnderline is the name of an UnderlineStyle, or empty if not underlined.
+// A straight underline is encoded as true, so that "underline" remains a
+// boolean for the common case.
+type jsonUnderline string
+
+func (u jsonUnderline) MarshalJSON() ([]byte, error) {
+	if u == jsonUnderline(StraightUnderline.String()) {
+		return []byte("true"), nil
+	}
+	return json.Marshal(string(u))
+}
+
+func (u *jsonUnderline) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*u = ""
+		if v {
+			*u = jsonUnderline(StraightUnderline.String())
+		}
+	case string:
+		*u = jsonUnderline(v)
+	default:
+		return errors.New("ansi: underline must be a boolean or a string")
+	}
+	return nil
 }
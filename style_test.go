@@ -0,0 +1,87 @@
+package ansi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestStyle_JSON(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		style       ansi.Style
+		json        string
+	}{
+		{
+			description: "empty",
+			style:       ansi.Style{},
+			json:        `{}`,
+		},
+		{
+			description: "colours",
+			style: ansi.Style{
+				Foreground: ansi.Red,
+				Background: ansi.RGB(0, 0, 0),
+			},
+			json: `{"fg":"red","bg":"#000000"}`,
+		},
+		{
+			description: "modifiers",
+			style: ansi.Style{
+				Modifier: ansi.Bold | ansi.Strikethrough | ansi.Overline,
+			},
+			json: `{"bold":true,"strikethrough":true,"overline":true}`,
+		},
+		{
+			description: "all modifiers",
+			style: ansi.Style{
+				Modifier: ansi.Bold |
+					ansi.Faint |
+					ansi.Italic |
+					ansi.Underline |
+					ansi.Blink |
+					ansi.Inverted |
+					ansi.Fraktur |
+					ansi.Framed |
+					ansi.RapidBlink |
+					ansi.Conceal |
+					ansi.Strikethrough |
+					ansi.Overline,
+			},
+			json: `{"bold":true,"faint":true,"italic":true,"underline":true,"blink":true,"inverted":true,"fraktur":true,"framed":true,` +
+				`"rapid_blink":true,"conceal":true,"strikethrough":true,"overline":true}`,
+		},
+		{
+			description: "underline style and colour",
+			style: ansi.Style{
+				Modifier:       ansi.Underline,
+				UnderlineStyle: ansi.CurlyUnderline,
+				UnderlineColor: ansi.RGB(255, 0, 0),
+			},
+			json: `{"underline":"curly","underline_color":"#ff0000"}`,
+		},
+		{
+			description: "double underline",
+			style: ansi.Style{
+				Modifier:       ansi.Underline,
+				UnderlineStyle: ansi.DoubleUnderline,
+			},
+			json: `{"underline":"double"}`,
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			marshalled, err := json.Marshal(tt.style)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(marshalled)).To(Equal(tt.json))
+
+			var style ansi.Style
+			err = json.Unmarshal(marshalled, &style)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(style).To(Equal(tt.style))
+		})
+	}
+}
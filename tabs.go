@@ -0,0 +1,90 @@
+package ansi
+
+// TabStops are the columns that Tab moves the cursor to. The zero value has a
+// tab stop every 8 columns.
+type TabStops struct {
+	// Whether the default tab stops have been cleared
+	cleared bool
+	// Tab stops that have been set (true) or cleared (false). Never modified
+	// in place, so that copies of a State don't share changes.
+	overrides map[int]bool
+}
+
+// IsDefault returns whether there is a tab stop every 8 columns, and no
+// others.
+func (t TabStops) IsDefault() bool {
+	return !t.cleared && len(t.overrides) == 0
+}
+
+func (t TabStops) isStop(col int) bool {
+	if stop, ok := t.overrides[col]; ok {
+		return stop
+	}
+	return !t.cleared && col%tabWidth == 0
+}
+
+// Next returns the first tab stop after col, if there is one.
+func (t TabStops) Next(col int) (int, bool) {
+	next, found := 0, false
+	if !t.cleared {
+		next = (col/tabWidth + 1) * tabWidth
+		for !t.isStop(next) {
+			next += tabWidth
+		}
+		found = true
+	}
+	for c, stop := range t.overrides {
+		if stop && c > col && (!found || c < next) {
+			next, found = c, true
+		}
+	}
+	return next, found
+}
+
+// Prev returns the last tab stop before col, or 0 if there is none.
+func (t TabStops) Prev(col int) int {
+	prev := 0
+	if !t.cleared && col > 0 {
+		prev = (col - 1) / tabWidth * tabWidth
+		for prev > 0 && !t.isStop(prev) {
+			prev -= tabWidth
+		}
+	}
+	for c, stop := range t.overrides {
+		if stop && c < col && c > prev {
+			prev = c
+		}
+	}
+	return prev
+}
+
+// Set adds a tab stop at col.
+func (t *TabStops) Set(col int) {
+	t.override(col, true)
+}
+
+// Clear removes the tab stop at col, if there is one.
+func (t *TabStops) Clear(col int) {
+	t.override(col, false)
+}
+
+// ClearAll removes all tab stops.
+func (t *TabStops) ClearAll() {
+	*t = TabStops{cleared: true}
+}
+
+func (t *TabStops) override(col int, stop bool) {
+	if t.isStop(col) == stop {
+		return
+	}
+	overrides := make(map[int]bool, len(t.overrides)+1)
+	for c, s := range t.overrides {
+		overrides[c] = s
+	}
+	if t.cleared && !stop {
+		delete(overrides, col)
+	} else {
+		overrides[col] = stop
+	}
+	t.overrides = overrides
+}
@@ -0,0 +1,48 @@
+package ansi_test
+
+import (
+	"testing"
+
+	"github.com/aoldershaw/ansi"
+	. "github.com/onsi/gomega"
+)
+
+func TestTabStops(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var stops ansi.TabStops
+	next := func(col int) int {
+		next, ok := stops.Next(col)
+		g.Expect(ok).To(BeTrue())
+		return next
+	}
+	g.Expect(stops.IsDefault()).To(BeTrue())
+	g.Expect(next(0)).To(Equal(8))
+	g.Expect(next(8)).To(Equal(16))
+	g.Expect(stops.Prev(16)).To(Equal(8))
+	g.Expect(stops.Prev(3)).To(Equal(0))
+
+	stops.Set(8)
+	g.Expect(stops.IsDefault()).To(BeTrue())
+
+	stops.Set(3)
+	stops.Clear(8)
+	g.Expect(stops.IsDefault()).To(BeFalse())
+	g.Expect(next(0)).To(Equal(3))
+	g.Expect(next(3)).To(Equal(16))
+	g.Expect(stops.Prev(16)).To(Equal(3))
+
+	copied := stops
+	copied.Set(5)
+	g.Expect(next(3)).To(Equal(16), "modified a copy")
+
+	stops.ClearAll()
+	_, ok := stops.Next(0)
+	g.Expect(ok).To(BeFalse())
+	g.Expect(stops.Prev(10)).To(Equal(0))
+
+	stops.Set(4)
+	g.Expect(next(0)).To(Equal(4))
+	_, ok = stops.Next(4)
+	g.Expect(ok).To(BeFalse())
+}
@@ -0,0 +1,270 @@
+package ansi
+
+import (
+	"encoding/binary"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text is measured in terminal cells. Most characters occupy a single cell,
+// but East Asian wide and fullwidth characters and emoji occupy two, and
+// combining marks and other format characters occupy none.
+//
+// Columns only ever fall on grapheme cluster boundaries: a character together
+// with any combining marks, variation selectors, emoji modifiers, or emoji
+// joined to it by a zero width joiner. This is a simplification of the rules
+// in UAX #29 (https://unicode.org/reports/tr29/) that matches how terminals
+// lay out text.
+
+const (
+	zeroWidthJoiner     = '\u200d'
+	emojiPresentation   = '\ufe0f'
+	softHyphen          = '\u00ad'
+	regionalIndicatorA  = '\U0001f1e6'
+	regionalIndicatorZ  = '\U0001f1ff'
+	emojiModifierFirst  = '\U0001f3fb'
+	emojiModifierLast   = '\U0001f3ff'
+	hangulJungseongMin  = '\u1160'
+	hangulJongseongMax  = '\u11ff'
+	hangulJungseongExtA = '\ud7b0'
+	hangulJongseongExtB = '\ud7ff'
+)
+
+// East Asian Wide (W) and Fullwidth (F) characters, including emoji with
+// default emoji presentation. Based on Unicode 15.
+var wideTable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f3, 3},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x2693, 20},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26d4, 6},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26fa, 5},
+		{0x26fd, 0x2705, 8},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x274c, 36},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27bf, 15},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b55, 5},
+		{0x2e80, 0x2e99, 1},
+		{0x2e9b, 0x2ef3, 1},
+		{0x2f00, 0x2fd5, 1},
+		{0x2ff0, 0x2fff, 1},
+		{0x3000, 0x303e, 1},
+		{0x3041, 0x3096, 1},
+		{0x3099, 0x30ff, 1},
+		{0x3105, 0x312f, 1},
+		{0x3131, 0x318e, 1},
+		{0x3190, 0x31e3, 1},
+		{0x31ef, 0x321e, 1},
+		{0x3220, 0x3247, 1},
+		{0x3250, 0x4dbf, 1},
+		{0x4e00, 0xa48c, 1},
+		{0xa490, 0xa4c6, 1},
+		{0xa960, 0xa97c, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe52, 1},
+		{0xfe54, 0xfe66, 1},
+		{0xfe68, 0xfe6b, 1},
+		{0xff01, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x16ff0, 0x16ff1, 1},
+		{0x17000, 0x187f7, 1},
+		{0x18800, 0x18cd5, 1},
+		{0x18d00, 0x18d08, 1},
+		{0x1aff0, 0x1aff3, 1},
+		{0x1aff5, 0x1affb, 1},
+		{0x1affd, 0x1affe, 1},
+		{0x1b000, 0x1b122, 1},
+		{0x1b132, 0x1b132, 1},
+		{0x1b150, 0x1b152, 1},
+		{0x1b155, 0x1b155, 1},
+		{0x1b164, 0x1b167, 1},
+		{0x1b170, 0x1b2fb, 1},
+		{0x1f004, 0x1f0cf, 203},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f202, 1},
+		{0x1f210, 0x1f23b, 1},
+		{0x1f240, 0x1f248, 1},
+		{0x1f250, 0x1f251, 1},
+		{0x1f260, 0x1f265, 1},
+		{0x1f300, 0x1f320, 1},
+		{0x1f32d, 0x1f335, 1},
+		{0x1f337, 0x1f37c, 1},
+		{0x1f37e, 0x1f393, 1},
+		{0x1f3a0, 0x1f3ca, 1},
+		{0x1f3cf, 0x1f3d3, 1},
+		{0x1f3e0, 0x1f3f0, 1},
+		{0x1f3f4, 0x1f3f4, 1},
+		{0x1f3f8, 0x1f43e, 1},
+		{0x1f440, 0x1f440, 1},
+		{0x1f442, 0x1f4fc, 1},
+		{0x1f4ff, 0x1f53d, 1},
+		{0x1f54b, 0x1f54e, 1},
+		{0x1f550, 0x1f567, 1},
+		{0x1f57a, 0x1f57a, 1},
+		{0x1f595, 0x1f596, 1},
+		{0x1f5a4, 0x1f5a4, 1},
+		{0x1f5fb, 0x1f64f, 1},
+		{0x1f680, 0x1f6c5, 1},
+		{0x1f6cc, 0x1f6cc, 1},
+		{0x1f6d0, 0x1f6d2, 1},
+		{0x1f6d5, 0x1f6d7, 1},
+		{0x1f6dc, 0x1f6df, 1},
+		{0x1f6eb, 0x1f6ec, 1},
+		{0x1f6f4, 0x1f6fc, 1},
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f7f0, 0x1f7f0, 1},
+		{0x1f90c, 0x1f93a, 1},
+		{0x1f93c, 0x1f945, 1},
+		{0x1f947, 0x1f9ff, 1},
+		{0x1fa70, 0x1fa7c, 1},
+		{0x1fa80, 0x1fa88, 1},
+		{0x1fa90, 0x1fabd, 1},
+		{0x1fabf, 0x1fac5, 1},
+		{0x1face, 0x1fadb, 1},
+		{0x1fae0, 0x1fae8, 1},
+		{0x1faf0, 0x1faf8, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// runeWidth returns the number of cells that r occupies on its own
+func runeWidth(r rune) int {
+	switch {
+	case r < utf8.RuneSelf:
+		return 1
+	case isZeroWidth(r):
+		return 0
+	case unicode.Is(wideTable, r):
+		return 2
+	}
+	return 1
+}
+
+func isZeroWidth(r rune) bool {
+	switch {
+	case r == softHyphen:
+		return false
+	case r >= hangulJungseongMin && r <= hangulJongseongMax,
+		r >= hangulJungseongExtA && r <= hangulJongseongExtB:
+		// Hangul vowels and trailing consonants, which combine with a
+		// preceding leading consonant
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// nextGrapheme returns the length in bytes and the width in cells of the
+// grapheme cluster at the start of data. Invalid UTF-8 is treated as one cell
+// per byte.
+func nextGrapheme(data []byte) (int, int) {
+	first, n := utf8.DecodeRune(data)
+	if first == utf8.RuneError && n <= 1 {
+		return 1, 1
+	}
+	width := runeWidth(first)
+	prev := first
+	for n < len(data) {
+		r, size := utf8.DecodeRune(data[n:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			return n, width
+		case prev == zeroWidthJoiner:
+			// e.g. the emoji in a family emoji sequence
+		case r == emojiPresentation:
+			width = 2
+		case isZeroWidth(r), r >= emojiModifierFirst && r <= emojiModifierLast:
+		case isRegionalIndicator(r) && isRegionalIndicator(first) && n == utf8.RuneLen(first):
+			// A pair of regional indicators is a flag
+			width = 2
+		default:
+			return n, width
+		}
+		prev = r
+		n += size
+	}
+	return n, width
+}
+
+// The distance between the tab stops assumed when measuring tabs in text
+const tabWidth = 8
+
+// graphemeAt returns the length in bytes and the width in cells of the
+// grapheme cluster starting at data[i], which is at column col. A tab extends
+// to the next multiple of tabWidth.
+func graphemeAt(data []byte, i int, col int) (int, int) {
+	if data[i] == '\t' {
+		return 1, tabWidth - col%tabWidth
+	}
+	// Fast path for ASCII that isn't followed by a combining character
+	if data[i] < utf8.RuneSelf && (i+1 == len(data) || data[i+1] < utf8.RuneSelf) {
+		return 1, 1
+	}
+	return nextGrapheme(data[i:])
+}
+
+// isASCIIWithoutTabs returns whether none of the 8 bytes packed into w is a
+// tab or has its high bit set
+func isASCIIWithoutTabs(w uint64) bool {
+	const (
+		ones  = 0x0101010101010101
+		highs = 0x8080808080808080
+		tabs  = '\t' * ones
+	)
+	// Any byte of w^tabs that is zero was a tab
+	t := w ^ tabs
+	return (w|(t-ones)&^t)&highs == 0
+}
+
+// textWidth returns the number of cells that data occupies when it starts at
+// column col
+func textWidth(data []byte, col int) int {
+	// Fast path for ASCII without tabs, each character of which occupies a
+	// cell
+	i := 0
+	for ; i+8 <= len(data); i += 8 {
+		if !isASCIIWithoutTabs(binary.LittleEndian.Uint64(data[i:])) {
+			break
+		}
+	}
+	for ; i < len(data) && data[i] != '\t' && data[i] < utf8.RuneSelf; i++ {
+	}
+	if i == len(data) {
+		return len(data)
+	}
+
+	width := 0
+	for i := 0; i < len(data); {
+		n, w := graphemeAt(data, i, col+width)
+		width += w
+		i += n
+	}
+	return width
+}
@@ -1,5 +1,7 @@
 package ansi
 
+import "io"
+
 const (
 	defaultLines = 48
 	defaultCols  = 80
@@ -17,15 +19,61 @@ type State struct {
 	LineDiscipline LineDiscipline
 	Position       Pos
 	SavedPosition  *Pos
+	// Link is the active OSC 8 hyperlink, or nil if there is none
+	Link     *Hyperlink
+	TabStops TabStops
+	// AlternateScreen is set while the alternate screen is in use
+	AlternateScreen bool
+
+	// The modes that affect how text is written. NewlineMode is tracked by
+	// LineDiscipline.
+	InsertMode    bool
+	OriginMode    bool
+	AutoWrap      bool
+	CursorVisible bool
+	// Modes holds any other modes that have been set or reset
+	Modes map[Mode]bool
+	// ScrollRegion is the region set by SetScrollRegion, or nil if the whole
+	// screen scrolls
+	ScrollRegion *ScrollRegion
 
 	MaxLine int
 	MaxCol  int
+
+	// The lines that scrolled off the top of the scroll region and were kept,
+	// without a fixed height, in order
+	kept []keptLines
 }
 
+// The size of the buffer used by Writer.ReadFrom
+const readBufferSize = 32 * 1024
+
 type Writer struct {
 	State
 	Parser *Parser
 	Output Output
+	// AlternateOutput is written to instead of Output while the alternate
+	// screen is in use
+	AlternateOutput Output
+
+	// Called with sequences that the parser doesn't support
+	unknownHandler func(Unknown)
+	literalTabs    bool
+
+	// Set if text wraps at MaxCol, which then never grows. This is the case
+	// for a FixedSizeOutput, or with WithFixedWidth.
+	fixedWidth bool
+	// Set if the Output is a FixedSizeOutput, in which case MaxLine never
+	// grows
+	fixedHeight bool
+	// Set when text has been printed in the last column with a fixed width.
+	// The next printed character wraps onto the next line.
+	wrapPending bool
+	// The state of the primary screen while the alternate screen is in use
+	primary State
+
+	// Reused between calls to WriteString and ReadFrom to avoid allocations
+	buf []byte
 }
 
 func NewWriter(output Output, opts ...WriterOption) *Writer {
@@ -35,17 +83,37 @@ func NewWriter(output Output, opts ...WriterOption) *Writer {
 			MaxCol:  defaultCols,
 
 			LineDiscipline: Cooked,
+			AutoWrap:       true,
+			CursorVisible:  true,
 		},
 		Parser: NewParser(),
 		Output: output,
+
+		AlternateOutput: &Lines{},
+	}
+	out, fixedSize := output.(FixedSizeOutput)
+	var lines, cols int
+	if fixedSize {
+		lines, cols = out.Size()
+		// Like a terminal, the alternate screen has no scrollback
+		w.AlternateOutput = NewScreen(lines, cols, WithScrollbackSize(0))
 	}
 	for _, opt := range opts {
 		opt(w)
 	}
+	if fixedSize {
+		w.MaxLine = lines - 1
+		w.MaxCol = cols - 1
+		w.fixedWidth = true
+		w.fixedHeight = true
+	} else if w.fixedWidth {
+		// MaxCol is the last column that text is printed in
+		w.MaxCol--
+	}
 	return w
 }
 
-func (w *Writer) Write(input []byte) (int64, error) {
+func (w *Writer) Write(input []byte) (int, error) {
 	n := len(input)
 	for {
 		action, ok, newInput := w.Parser.Parse(input)
@@ -53,24 +121,103 @@ func (w *Writer) Write(input []byte) (int64, error) {
 			break
 		}
 		if err := w.Action(action); err != nil {
-			return int64(n - len(input)), err
+			// input may include bytes carried over from a previous Write
+			written := n - len(input)
+			if written < 0 {
+				written = 0
+			}
+			return written, err
 		}
 		input = newInput
 	}
-	return int64(n), nil
+	return n, nil
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	w.buf = append(w.buf[:0], s...)
+	return w.Write(w.buf)
+}
+
+// ReadFrom writes everything read from r until EOF, reusing a single buffer
+// for all reads.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if cap(w.buf) < readBufferSize {
+		w.buf = make([]byte, readBufferSize)
+	}
+	buf := w.buf[:readBufferSize]
+	var total int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			written, writeErr := w.Write(buf[:n])
+			total += int64(written)
+			if writeErr != nil {
+				return total, writeErr
+			}
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
 }
 
 func (w *Writer) Action(act Action) error {
+	if w.wrapPending && movesCursor(act) {
+		w.wrapPending = false
+	}
 	switch v := act.(type) {
 	case Print:
-		if err := w.Output.Print(v, w.Style, w.Position); err != nil {
+		if w.fixedWidth {
+			return w.printWrapped(v)
+		}
+		width := textWidth(v, w.Position.Col)
+		if err := w.insertCells(width); err != nil {
 			return err
 		}
-		endCol := w.Position.Col + len(v)
-		if endCol > w.MaxCol {
-			w.MaxCol = endCol
+		if err := w.print(v); err != nil {
+			return err
+		}
+		w.advanceTo(w.Position.Col + width)
+	case Tab:
+		for i := 0; i < int(v); i++ {
+			next, ok := w.TabStops.Next(w.Position.Col)
+			if !ok {
+				break
+			}
+			// Lines can only measure literal tabs with the default tab stops
+			if w.literalTabs && !w.fixedWidth && w.TabStops.IsDefault() {
+				if err := w.print([]byte{'\t'}); err != nil {
+					return err
+				}
+			}
+			w.advanceTo(next)
+		}
+	case BackTab:
+		for i := 0; i < int(v); i++ {
+			w.Position.Col = w.TabStops.Prev(w.Position.Col)
+		}
+	case SetTabStop:
+		w.TabStops.Set(w.Position.Col)
+	case ClearTabStop:
+		w.TabStops.Clear(w.Position.Col)
+	case ClearAllTabStops:
+		w.TabStops.ClearAll()
+	case Unknown:
+		if w.unknownHandler != nil {
+			w.unknownHandler(v)
+		}
+	case SetHyperlink:
+		if v.URI == "" {
+			w.Link = nil
+		} else {
+			// Allocate a new link rather than modifying the existing one,
+			// since outputs may retain it
+			link := Hyperlink(v)
+			w.Link = &link
 		}
-		w.Position.Col = endCol
 	case Reset:
 		w.Style = Style{}
 	case SetForeground:
@@ -85,6 +232,12 @@ func (w *Writer) Action(act Action) error {
 		w.Style.Modifier.applyBit(bool(v), Italic)
 	case SetUnderline:
 		w.Style.Modifier.applyBit(bool(v), Underline)
+		w.Style.UnderlineStyle = StraightUnderline
+	case SetUnderlineStyle:
+		w.Style.Modifier.applyBit(true, Underline)
+		w.Style.UnderlineStyle = UnderlineStyle(v)
+	case SetUnderlineColor:
+		w.Style.UnderlineColor = Color(v)
 	case SetBlink:
 		w.Style.Modifier.applyBit(bool(v), Blink)
 	case SetInverted:
@@ -93,8 +246,26 @@ func (w *Writer) Action(act Action) error {
 		w.Style.Modifier.applyBit(bool(v), Fraktur)
 	case SetFramed:
 		w.Style.Modifier.applyBit(bool(v), Framed)
+	case SetRapidBlink:
+		w.Style.Modifier.applyBit(bool(v), RapidBlink)
+	case SetConceal:
+		w.Style.Modifier.applyBit(bool(v), Conceal)
+	case SetStrikethrough:
+		w.Style.Modifier.applyBit(bool(v), Strikethrough)
+	case SetOverline:
+		w.Style.Modifier.applyBit(bool(v), Overline)
 	case CursorPosition:
-		w.moveCursorTo(v.Line, v.Col)
+		line := w.screenLine(v.Line)
+		if w.OriginMode && w.ScrollRegion != nil {
+			line = w.outputLine(w.screenRow(w.ScrollRegion.Top) + v.Line - 1)
+			if line < w.ScrollRegion.Top {
+				line = w.ScrollRegion.Top
+			}
+			if line > w.ScrollRegion.Bottom {
+				line = w.ScrollRegion.Bottom
+			}
+		}
+		w.moveCursorTo(line, w.screenCol(v.Col))
 	case CursorUp:
 		w.moveCursor(-int(v), 0)
 	case CursorDown:
@@ -104,20 +275,44 @@ func (w *Writer) Action(act Action) error {
 	case CursorBack:
 		w.moveCursor(0, -int(v))
 	case CursorColumn:
-		w.moveCursorTo(w.Position.Line, int(v))
-	case Linebreak:
-		switch w.LineDiscipline {
-		case Raw:
-			w.Position.Line++
-		case Cooked:
-			w.Position.Line++
+		w.moveCursorTo(w.Position.Line, w.screenCol(int(v)))
+	case Linebreak, VerticalTab, FormFeed:
+		if err := w.index(); err != nil {
+			return err
+		}
+		if w.LineDiscipline == Cooked {
 			w.Position.Col = 0
 		}
-		if w.Position.Line > w.MaxLine {
-			w.MaxLine = w.Position.Line
+	case Index:
+		return w.index()
+	case ReverseIndex:
+		top, _ := w.scrollBounds()
+		if w.Position.Line == top {
+			return w.scroll(-1)
 		}
+		w.moveCursor(-1, 0)
+	case ScrollUp:
+		return w.scroll(int(v))
+	case ScrollDown:
+		return w.scroll(-int(v))
+	case SetScrollRegion:
+		w.setScrollRegion(ScrollRegion(v))
+	case SetMode:
+		w.setMode(Mode(v), true)
+	case ResetMode:
+		w.setMode(Mode(v), false)
+	case EnterAlternateScreen:
+		return w.enterAlternateScreen(AlternateScreenMode(v))
+	case ExitAlternateScreen:
+		w.exitAlternateScreen(AlternateScreenMode(v))
 	case CarriageReturn:
 		w.Position.Col = 0
+	case Backspace:
+		if w.Position.Col > 0 {
+			w.Position.Col--
+		}
+	case Bell:
+		// There's nothing to ring
 	case SaveCursorPosition:
 		pos := w.Position
 		w.SavedPosition = &pos
@@ -125,35 +320,418 @@ func (w *Writer) Action(act Action) error {
 		if w.SavedPosition != nil {
 			w.Position = *w.SavedPosition
 		}
+	case InsertCharacters:
+		if out, ok := w.output().(LineEditor); ok {
+			return out.InsertCharacters(int(v), w.Position)
+		}
+	case DeleteCharacters:
+		if out, ok := w.output().(LineEditor); ok {
+			return out.DeleteCharacters(int(v), w.Position)
+		}
+	case EraseCharacters:
+		if v > 0 {
+			return w.output().Print(spacer(int(v)), Style{}, w.Position)
+		}
+	case InsertLines:
+		w.Position.Col = 0
+		return w.editLines(int(v))
+	case DeleteLines:
+		w.Position.Col = 0
+		return w.editLines(-int(v))
 	case EraseLine:
-		startOfLine := w.Position
-		startOfLine.Col = 0
-		switch EraseMode(v) {
-		case EraseToBeginning:
-			if w.Position.Col == 0 {
-				return nil
-			}
-			empty := spacer(w.Position.Col)
-			w.Output.Print(empty, Style{}, startOfLine)
-		case EraseToEnd:
-			pos := w.Position
-			pos.Col++
-			if err := w.Output.ClearRight(pos); err != nil {
+		return w.eraseLine(EraseMode(v))
+	case EraseDisplay:
+		return w.eraseDisplay(EraseMode(v))
+	}
+
+	return nil
+}
+
+// output returns the Output for the screen that is in use
+func (w *Writer) output() Output {
+	if w.AlternateScreen {
+		return w.AlternateOutput
+	}
+	return w.Output
+}
+
+func (w *Writer) enterAlternateScreen(mode AlternateScreenMode) error {
+	if w.AlternateScreen {
+		return nil
+	}
+	w.primary = w.State
+	w.AlternateScreen = true
+	w.wrapPending = false
+	// The lines that were kept are only on the primary screen
+	w.mapLines(w.screenRow)
+	w.kept = nil
+	if mode == AlternateScreen {
+		return nil
+	}
+	// Other modes clear the alternate screen, which we do on the way in
+	// rather than on the way out so that its contents can still be seen
+	if out, ok := w.AlternateOutput.(DisplayEraser); ok {
+		return out.ClearAll()
+	}
+	return nil
+}
+
+// exitAlternateScreen switches back to the primary screen. With
+// AlternateScreenSaveCursor, the cursor is restored as it was before the
+// alternate screen was entered. Otherwise it stays where it is.
+func (w *Writer) exitAlternateScreen(mode AlternateScreenMode) {
+	if !w.AlternateScreen {
+		return
+	}
+	w.AlternateScreen = false
+	w.wrapPending = false
+	if mode == AlternateScreenSaveCursor {
+		w.Position = w.primary.Position
+		w.SavedPosition = w.primary.SavedPosition
+		w.ScrollRegion = w.primary.ScrollRegion
+		w.MaxLine = w.primary.MaxLine
+		w.MaxCol = w.primary.MaxCol
+		w.kept = w.primary.kept
+		return
+	}
+	w.mapLines(w.screenRow)
+	w.kept = w.primary.kept
+	w.mapLines(w.outputLine)
+	w.MaxLine = w.primary.MaxLine
+	w.MaxCol = w.primary.MaxCol
+	if w.Position.Line > w.MaxLine {
+		w.MaxLine = w.Position.Line
+	}
+}
+
+// index moves the cursor down a line, scrolling if it is at the bottom of the
+// scroll region
+func (w *Writer) index() error {
+	if w.ScrollRegion != nil && w.Position.Line == w.ScrollRegion.Bottom {
+		return w.scroll(1)
+	}
+	if w.fixedHeight && w.Position.Line >= w.MaxLine {
+		return w.scroll(1)
+	}
+	w.Position.Line = w.outputLine(w.screenRow(w.Position.Line) + 1)
+	if w.Position.Line > w.MaxLine {
+		w.MaxLine = w.Position.Line
+	}
+	return nil
+}
+
+// scrollBounds returns the first and last lines that scroll
+func (w *Writer) scrollBounds() (int, int) {
+	if w.ScrollRegion == nil {
+		return w.outputLine(0), w.MaxLine
+	}
+	return w.ScrollRegion.Top, w.ScrollRegion.Bottom
+}
+
+// scroll scrolls the scroll region up n lines, or down if n is negative
+func (w *Writer) scroll(n int) error {
+	out, ok := w.output().(LineEditor)
+	if !ok {
+		return nil
+	}
+	top, bottom := w.scrollBounds()
+	if n < 0 {
+		return out.ScrollDown(-n, top, bottom)
+	}
+	if w.fixedHeight {
+		return out.ScrollUp(n, top, bottom)
+	}
+	// Without a fixed height, the lines that scroll off the top are kept, like
+	// a terminal's scrollback, and blank lines are inserted below the region
+	// instead
+	if height := w.screenRow(bottom) - w.screenRow(top) + 1; n > height {
+		n = height
+	}
+	if err := out.InsertLines(n, bottom+1); err != nil {
+		return err
+	}
+	w.keepLines(n, top, bottom)
+	return nil
+}
+
+// screenLine returns the line that a cursor position action refers to, after
+// any lines that have been kept by scrolling
+func (w *Writer) screenLine(line int) int {
+	if w.fixedHeight {
+		// A FixedSizeOutput is addressed like a terminal, counting from 1
+		if line < 1 {
+			return 0
+		}
+		return line - 1
+	}
+	return w.outputLine(line)
+}
+
+// screenCol returns the column that a cursor position action refers to
+func (w *Writer) screenCol(col int) int {
+	if w.fixedHeight {
+		return col - 1
+	}
+	return col
+}
+
+// editLines inserts n lines at the cursor, or deletes them if n is negative.
+// Within a scroll region, only the lines between the cursor and the bottom
+// margin move.
+func (w *Writer) editLines(n int) error {
+	out, ok := w.output().(LineEditor)
+	if !ok {
+		return nil
+	}
+	line := w.Position.Line
+	if r := w.ScrollRegion; r != nil {
+		if line < r.Top || line > r.Bottom {
+			return nil
+		}
+		if n < 0 {
+			return out.ScrollUp(-n, line, r.Bottom)
+		}
+		return out.ScrollDown(n, line, r.Bottom)
+	}
+	if n < 0 {
+		return out.DeleteLines(-n, line)
+	}
+	return out.InsertLines(n, line)
+}
+
+func (w *Writer) setScrollRegion(region ScrollRegion) {
+	if region.Bottom == 0 {
+		region.Bottom = w.MaxLine
+	} else {
+		region.Bottom = w.screenLine(region.Bottom)
+	}
+	if w.fixedHeight && region.Bottom > w.MaxLine {
+		region.Bottom = w.MaxLine
+	}
+	if region.Top < 0 {
+		return
+	}
+	region.Top = w.screenLine(region.Top)
+	if region.Top >= region.Bottom {
+		return
+	}
+	if region.Top == w.screenLine(0) && region.Bottom == w.MaxLine {
+		w.ScrollRegion = nil
+	} else {
+		w.ScrollRegion = &region
+	}
+	w.Position = w.home()
+}
+
+// home returns the position that the cursor returns to when the scroll region
+// or origin mode changes
+func (w *Writer) home() Pos {
+	if w.OriginMode && w.ScrollRegion != nil {
+		return Pos{Line: w.ScrollRegion.Top}
+	}
+	return Pos{Line: w.screenLine(0)}
+}
+
+func (w *Writer) setMode(mode Mode, enabled bool) {
+	switch mode {
+	case InsertMode:
+		w.InsertMode = enabled
+	case NewlineMode:
+		if enabled {
+			w.LineDiscipline = Cooked
+		} else {
+			w.LineDiscipline = Raw
+		}
+	case OriginMode:
+		w.OriginMode = enabled
+		w.Position = w.home()
+	case AutoWrapMode:
+		w.AutoWrap = enabled
+		w.wrapPending = false
+	case CursorVisibleMode:
+		w.CursorVisible = enabled
+	default:
+		if w.Modes == nil {
+			w.Modes = make(map[Mode]bool)
+		}
+		w.Modes[mode] = enabled
+	}
+}
+
+func (w *Writer) eraseLine(mode EraseMode) error {
+	startOfLine := w.Position
+	startOfLine.Col = 0
+	switch mode {
+	case EraseToBeginning:
+		if w.Position.Col == 0 {
+			return nil
+		}
+		empty := spacer(w.Position.Col)
+		return w.output().Print(empty, Style{}, startOfLine)
+	case EraseToEnd:
+		pos := w.Position
+		pos.Col++
+		return w.output().ClearRight(pos)
+	case EraseAll:
+		return w.output().ClearRight(startOfLine)
+	}
+	return nil
+}
+
+func (w *Writer) eraseDisplay(mode EraseMode) error {
+	out, ok := w.output().(DisplayEraser)
+	if !ok {
+		return nil
+	}
+	switch mode {
+	case EraseToEnd:
+		if err := w.eraseLine(EraseToEnd); err != nil {
+			return err
+		}
+		if len(w.kept) > 0 {
+			return w.eraseScreenLines(out, w.Position.Line+1, -1)
+		}
+		return out.ClearBelow(w.Position.Line)
+	case EraseToBeginning:
+		if err := w.eraseLine(EraseToBeginning); err != nil {
+			return err
+		}
+		if len(w.kept) > 0 {
+			return w.eraseScreenLines(out, 0, w.Position.Line)
+		}
+		return out.ClearAbove(w.Position.Line)
+	case EraseAll:
+		if len(w.kept) > 0 {
+			return w.eraseScreenLines(out, 0, -1)
+		}
+		return out.ClearAll()
+	case EraseScrollback:
+		if editor, ok := out.(LineEditor); ok && len(w.kept) > 0 {
+			if err := w.clearKept(editor); err != nil {
 				return err
 			}
-		case EraseAll:
-			if err := w.Output.ClearRight(startOfLine); err != nil {
+		}
+		return out.ClearScrollback()
+	}
+	return nil
+}
+
+func (w *Writer) print(data []byte) error {
+	if w.Link != nil {
+		if out, ok := w.output().(HyperlinkOutput); ok {
+			return out.PrintHyperlink(data, w.Style, w.Link, w.Position)
+		}
+	}
+	return w.output().Print(data, w.Style, w.Position)
+}
+
+// movesCursor returns whether an action (other than Print) moves the cursor,
+// or otherwise cancels a pending wrap
+func movesCursor(act Action) bool {
+	switch act.(type) {
+	case CursorUp, CursorDown, CursorForward, CursorBack, CursorPosition, CursorColumn,
+		Linebreak, CarriageReturn, Backspace, VerticalTab, FormFeed, Tab, BackTab,
+		Index, ReverseIndex, RestoreCursorPosition, SetScrollRegion,
+		InsertCharacters, DeleteCharacters, InsertLines, DeleteLines,
+		EraseLine, EraseDisplay:
+		return true
+	}
+	return false
+}
+
+// printWrapped prints data with a fixed width, wrapping onto the next line
+// when the last column is reached
+func (w *Writer) printWrapped(data []byte) error {
+	for len(data) > 0 {
+		if w.wrapPending && w.AutoWrap {
+			w.wrapPending = false
+			if out, ok := w.output().(WrapMarker); ok {
+				if err := out.MarkWrapped(w.Position.Line); err != nil {
+					return err
+				}
+			}
+			w.Position.Col = 0
+			if err := w.index(); err != nil {
 				return err
 			}
+		} else if w.wrapPending {
+			// Without auto-wrap, each character overwrites the last column, so
+			// only the last one is left there
+			w.wrapPending = false
+			last, width := 0, 0
+			for i := 0; i < len(data); {
+				size, cells := graphemeAt(data, i, 0)
+				last, width = i, cells
+				i += size
+			}
+			data = data[last:]
+			w.Position.Col = w.MaxCol + 1 - width
+			if w.Position.Col < 0 {
+				w.Position.Col = 0
+			}
+		}
+		// Print as much as fits on the line
+		col := w.Position.Col
+		n := 0
+		for n < len(data) {
+			size, width := graphemeAt(data, n, col)
+			if col+width > w.MaxCol+1 {
+				break
+			}
+			col += width
+			n += size
+		}
+		if n == 0 {
+			// A wide character doesn't fit in the last column
+			if w.Position.Col == 0 {
+				// It never will, so drop it
+				size, _ := graphemeAt(data, 0, 0)
+				data = data[size:]
+			} else {
+				w.wrapPending = true
+			}
+			continue
+		}
+		if err := w.insertCells(col - w.Position.Col); err != nil {
+			return err
+		}
+		if err := w.print(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		if col > w.MaxCol {
+			w.Position.Col = w.MaxCol
+			w.wrapPending = true
+		} else {
+			w.Position.Col = col
 		}
-
-	case EraseDisplay:
-		// unsupported
 	}
+	return nil
+}
 
+// insertCells makes room for n cells of text at the cursor in insert mode
+func (w *Writer) insertCells(n int) error {
+	if !w.InsertMode {
+		return nil
+	}
+	if out, ok := w.output().(LineEditor); ok {
+		return out.InsertCharacters(n, w.Position)
+	}
 	return nil
 }
 
+// advanceTo moves the cursor forward to col, as if text had been printed up to
+// it
+func (w *Writer) advanceTo(col int) {
+	if w.fixedWidth && col > w.MaxCol {
+		col = w.MaxCol
+	}
+	if col > w.MaxCol {
+		w.MaxCol = col
+	}
+	w.Position.Col = col
+}
+
 func (w *Writer) moveCursorTo(l, c int) {
 	w.Position.Line = l
 	w.Position.Col = c
@@ -172,7 +750,16 @@ func (w *Writer) moveCursorTo(l, c int) {
 }
 
 func (w *Writer) moveCursor(dl, dc int) {
-	w.moveCursorTo(w.Position.Line+dl, w.Position.Col+dc)
+	line := w.Position.Line + dl
+	if dl != 0 && len(w.kept) > 0 {
+		// Skip over the lines that were kept, which aren't on the screen
+		row := w.screenRow(w.Position.Line) + dl
+		if row < 0 {
+			row = 0
+		}
+		line = w.outputLine(row)
+	}
+	w.moveCursorTo(line, w.Position.Col+dc)
 }
 
 type WriterOption func(*Writer)
@@ -193,3 +780,61 @@ func WithInitialScreenSize(lines, cols int) WriterOption {
 		}
 	}
 }
+
+// WithFixedWidth wraps text at the width set by WithInitialScreenSize (80
+// columns by default), as a terminal would, rather than letting lines grow
+// without limit. Lines that are wrapped are marked as such if the Output is a
+// WrapMarker, such as Lines. Text always wraps if the Output is a
+// FixedSizeOutput.
+func WithFixedWidth() WriterOption {
+	return func(w *Writer) {
+		w.fixedWidth = true
+	}
+}
+
+// WithUnknownHandler registers a function that is called with each escape
+// sequence that is not supported, e.g. for collecting metrics. Such sequences
+// are otherwise ignored. The Raw bytes are only valid until the function
+// returns.
+func WithUnknownHandler(handler func(Unknown)) WriterOption {
+	return func(w *Writer) {
+		w.unknownHandler = handler
+	}
+}
+
+// WithLiteralTabs makes tabs appear in the output as literal tab characters,
+// rather than as the spaces that precede the text after them. Tabs only
+// appear literally while the tab stops are the default of every 8 columns,
+// and Lines only keeps a literal tab where it doesn't cover any text.
+func WithLiteralTabs() WriterOption {
+	return func(w *Writer) {
+		w.literalTabs = true
+	}
+}
+
+// WithParserOptions configures the Parser that the Writer uses, e.g.
+// WithoutControlCharacters to drop stray control characters from the text.
+func WithParserOptions(opts ...ParserOption) WriterOption {
+	return func(w *Writer) {
+		w.Parser = NewParser(opts...)
+	}
+}
+
+// WithAlternateOutput sets the Output that is written to while the alternate
+// screen is in use, e.g. by a full-screen program. By default, this is a new
+// Lines, or a new Screen if the Writer's Output is a FixedSizeOutput. If
+// output is nil, whatever is written to the alternate screen is discarded.
+func WithAlternateOutput(output Output) WriterOption {
+	return func(w *Writer) {
+		if output == nil {
+			output = discardOutput{}
+		}
+		w.AlternateOutput = output
+	}
+}
+
+// discardOutput is an Output that discards everything
+type discardOutput struct{}
+
+func (discardOutput) Print(data []byte, style Style, pos Pos) error { return nil }
+func (discardOutput) ClearRight(pos Pos) error                      { return nil }
@@ -2,7 +2,11 @@ package ansi_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/aoldershaw/ansi"
 	. "github.com/onsi/gomega"
@@ -11,6 +15,7 @@ import (
 type printCall struct {
 	data  []byte
 	style ansi.Style
+	link  *ansi.Hyperlink
 	pos   ansi.Pos
 }
 
@@ -32,6 +37,16 @@ func (p *spyOutput) Print(data []byte, style ansi.Style, pos ansi.Pos) error {
 	return nil
 }
 
+func (p *spyOutput) PrintHyperlink(data []byte, style ansi.Style, link *ansi.Hyperlink, pos ansi.Pos) error {
+	p.printCalls = append(p.printCalls, printCall{
+		data:  data,
+		style: style,
+		link:  link,
+		pos:   pos,
+	})
+	return nil
+}
+
 func (p *spyOutput) ClearRight(pos ansi.Pos) error {
 	p.clearCalls = append(p.clearCalls, clearCall{
 		pos: pos,
@@ -58,6 +73,25 @@ func TestWriter_Action(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "tracks hyperlinks",
+			actions: []ansi.Action{
+				ansi.SetHyperlink(ansi.Hyperlink{URI: "https://example.com"}),
+				ansi.Print("link"),
+				ansi.SetHyperlink(ansi.Hyperlink{}),
+				ansi.Print("text"),
+			},
+			printCalls: []printCall{
+				{
+					data: []byte("link"),
+					link: &ansi.Hyperlink{URI: "https://example.com"},
+				},
+				{
+					data: []byte("text"),
+					pos:  ansi.Pos{Col: 4},
+				},
+			},
+		},
 		{
 			description: "applies styles",
 			actions: []ansi.Action{
@@ -71,6 +105,10 @@ func TestWriter_Action(t *testing.T) {
 				ansi.SetFaint(true),
 				ansi.SetBlink(true),
 				ansi.SetFramed(true),
+				ansi.SetRapidBlink(true),
+				ansi.SetConceal(true),
+				ansi.SetStrikethrough(true),
+				ansi.SetOverline(true),
 				ansi.Print("some nicely formatted bytes here"),
 			},
 			printCalls: []printCall{
@@ -86,7 +124,69 @@ func TestWriter_Action(t *testing.T) {
 							ansi.Blink |
 							ansi.Inverted |
 							ansi.Fraktur |
-							ansi.Framed,
+							ansi.Framed |
+							ansi.RapidBlink |
+							ansi.Conceal |
+							ansi.Strikethrough |
+							ansi.Overline,
+					},
+				},
+			},
+		},
+		{
+			description: "unsets styles",
+			actions: []ansi.Action{
+				ansi.SetBold(true),
+				ansi.SetItalic(true),
+				ansi.SetUnderline(true),
+				ansi.SetInverted(true),
+				ansi.SetBold(false),
+				ansi.SetUnderline(false),
+				ansi.Print("only italic and inverted"),
+			},
+			printCalls: []printCall{
+				{
+					data: []byte("only italic and inverted"),
+					style: ansi.Style{
+						Modifier: ansi.Italic | ansi.Inverted,
+					},
+				},
+			},
+		},
+		{
+			description: "applies underline styles",
+			actions: []ansi.Action{
+				ansi.SetUnderlineStyle(ansi.CurlyUnderline),
+				ansi.SetUnderlineColor(ansi.RGB(255, 0, 0)),
+				ansi.Print("curly"),
+				ansi.SetUnderline(true),
+				ansi.Print("straight"),
+				ansi.SetUnderlineStyle(ansi.DottedUnderline),
+				ansi.SetUnderline(false),
+				ansi.Print("not underlined"),
+			},
+			printCalls: []printCall{
+				{
+					data: []byte("curly"),
+					style: ansi.Style{
+						Modifier:       ansi.Underline,
+						UnderlineStyle: ansi.CurlyUnderline,
+						UnderlineColor: ansi.RGB(255, 0, 0),
+					},
+				},
+				{
+					data: []byte("straight"),
+					pos:  ansi.Pos{Col: 5},
+					style: ansi.Style{
+						Modifier:       ansi.Underline,
+						UnderlineColor: ansi.RGB(255, 0, 0),
+					},
+				},
+				{
+					data: []byte("not underlined"),
+					pos:  ansi.Pos{Col: 13},
+					style: ansi.Style{
+						UnderlineColor: ansi.RGB(255, 0, 0),
 					},
 				},
 			},
@@ -373,6 +473,40 @@ func TestWriter_Action(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "inserting and deleting requires a LineEditor",
+			actions: []ansi.Action{
+				ansi.Print("some bytes"),
+				ansi.InsertCharacters(1),
+				ansi.DeleteCharacters(1),
+				ansi.InsertLines(1),
+				ansi.EraseCharacters(2),
+				ansi.Print("x"),
+			},
+			printCalls: []printCall{
+				{
+					data: []byte("some bytes"),
+				},
+				{
+					data: []byte("  "),
+				},
+				{
+					data: []byte("x"),
+				},
+			},
+		},
+		{
+			description: "erasing the display requires a DisplayEraser",
+			actions: []ansi.Action{
+				ansi.Print("some bytes"),
+				ansi.EraseDisplay(ansi.EraseAll),
+			},
+			printCalls: []printCall{
+				{
+					data: []byte("some bytes"),
+				},
+			},
+		},
 	} {
 		t.Run(tt.description, func(t *testing.T) {
 			g := NewGomegaWithT(t)
@@ -388,3 +522,828 @@ func TestWriter_Action(t *testing.T) {
 		})
 	}
 }
+
+var (
+	_ io.Writer       = (*ansi.Writer)(nil)
+	_ io.StringWriter = (*ansi.Writer)(nil)
+	_ io.ReaderFrom   = (*ansi.Writer)(nil)
+)
+
+type plainOutput struct {
+	printCalls []printCall
+}
+
+func (p *plainOutput) Print(data []byte, style ansi.Style, pos ansi.Pos) error {
+	p.printCalls = append(p.printCalls, printCall{data: data, style: style, pos: pos})
+	return nil
+}
+
+func (p *plainOutput) ClearRight(pos ansi.Pos) error {
+	return nil
+}
+
+func TestWriter_HyperlinksWithoutHyperlinkOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	output := &plainOutput{}
+	writer := ansi.NewWriter(output)
+	writer.Action(ansi.SetHyperlink(ansi.Hyperlink{URI: "https://example.com"}))
+	writer.Action(ansi.Print("link"))
+
+	g.Expect(writer.Link).To(Equal(&ansi.Hyperlink{URI: "https://example.com"}))
+	g.Expect(output.printCalls).To(Equal([]printCall{{data: []byte("link")}}))
+}
+
+func TestWriter_UnknownHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var (
+		lines   ansi.Lines
+		unknown []string
+	)
+	writer := ansi.NewWriter(&lines, ansi.WithUnknownHandler(func(u ansi.Unknown) {
+		unknown = append(unknown, string(u.Raw))
+	}))
+
+	_, err := writer.Write([]byte("\x1b[?6nhello \x1b[1;69mworld\x1b[1y"))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(unknown).To(Equal([]string{"\x1b[?6n", "\x1b[1;69m", "\x1b[1y"}))
+	g.Expect(lines).To(Equal(ansi.Lines{
+		{
+			{Data: ansi.Text("hello ")},
+			{Data: ansi.Text("world"), Style: ansi.Style{Modifier: ansi.Bold}},
+		},
+	}))
+}
+
+func TestWriter_DisplayWidth(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		input       string
+		col         int
+	}{
+		{description: "ascii", input: "hello", col: 5},
+		{description: "accented", input: "caf\u00e9", col: 4},
+		{description: "combining marks", input: "cafe\u0301", col: 4},
+		{description: "wide characters", input: "日本", col: 4},
+		{description: "fullwidth characters", input: "\uff21\uff22", col: 4},
+		{description: "emoji", input: "\U0001f600", col: 2},
+		{description: "emoji presentation selector", input: "\u2764\ufe0f", col: 2},
+		{description: "zwj sequences", input: "\U0001f468\u200d\U0001f469\u200d\U0001f467", col: 2},
+		{description: "skin tone modifiers", input: "\U0001f44d\U0001f3fd", col: 2},
+		{description: "flags", input: "\U0001f1ef\U0001f1f5\U0001f1fa\U0001f1f8", col: 4},
+		{description: "hangul jamo", input: "\u1100\u1161\u11a8", col: 2},
+		{description: "invalid utf-8", input: "\xff\xfeok", col: 4},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			writer := ansi.NewWriter(&ansi.Lines{})
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(writer.Position.Col).To(Equal(tt.col))
+		})
+	}
+}
+
+func TestWriter_Tabs(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		opts        []ansi.WriterOption
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "tabs move to the next tab stop",
+			input:       "a\tb\tc\rd\te\x1b[2Zf",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("f       e       c")}},
+			},
+		},
+		{
+			description: "custom tab stops",
+			input:       "\x1b[3g\x1b[3C\x1bH\x1b[3C\x1bH\ra\tb\tc\td\r\x1b[3C\x1b[g\ra\tb",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a  b  bd")}},
+			},
+		},
+		{
+			description: "literal tabs",
+			opts:        []ansi.WriterOption{ansi.WithLiteralTabs()},
+			input:       "a\tb\x1b[2Ic\rx\t|",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("x\t|\t\tc")}},
+			},
+		},
+		{
+			description: "literal tabs don't overwrite text",
+			opts:        []ansi.WriterOption{ansi.WithLiteralTabs()},
+			input:       "abcdefghij\r\tX\r\t\tY\tZ",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("abcdefghXj      Y\tZ")}},
+			},
+		},
+		{
+			description: "literal tabs are expanded with custom tab stops",
+			opts:        []ansi.WriterOption{ansi.WithLiteralTabs()},
+			input:       "\x1b[2C\x1bH\ra\tb",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a b")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines, tt.opts...)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_ControlCharacters(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		opts        []ansi.WriterOption
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "backspace moves back a column",
+			input:       "\b50%\b\b\b60%\a",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("60%")}},
+			},
+		},
+		{
+			description: "overstrike",
+			input:       "_\bu_\bs_\be",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("use")}},
+			},
+		},
+		{
+			description: "vertical tabs and form feeds are linebreaks",
+			input:       "one\vtwo\fthree",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+			},
+		},
+		{
+			description: "raw vertical tabs",
+			opts:        []ansi.WriterOption{ansi.WithLineDiscipline(ansi.Raw)},
+			input:       "one\vtwo",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("   two")}},
+			},
+		},
+		{
+			description: "other control characters are printed",
+			input:       "a\x00b",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a\x00b")}},
+			},
+		},
+		{
+			description: "other control characters can be dropped",
+			opts: []ansi.WriterOption{
+				ansi.WithParserOptions(ansi.WithoutControlCharacters()),
+			},
+			input: "a\x00b\x7f",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("ab")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines, tt.opts...)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_EraseDisplay(t *testing.T) {
+	const screen = "one\ntwo\nthree\nfour\x1b[1;2H"
+	for _, tt := range []struct {
+		description string
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "erase below",
+			input:       screen + "\x1b[J",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+			},
+		},
+		{
+			description: "erase above",
+			input:       screen + "\x1b[1J",
+			lines: ansi.Lines{
+				{},
+				{{Data: ansi.Text("  o")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+			},
+		},
+		{
+			description: "erase all",
+			input:       screen + "\x1b[2Jhi",
+			lines: ansi.Lines{
+				{},
+				{{Data: ansi.Text("  hi")}},
+			},
+		},
+		{
+			description: "clear",
+			input:       screen + "\x1b[0;0H\x1b[2J\x1b[3Jhi",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("hi")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_InsertAndDelete(t *testing.T) {
+	bold := ansi.Style{Modifier: ansi.Bold}
+	for _, tt := range []struct {
+		description string
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "insert characters",
+			input:       "ab\x1b[1mcd\x1b[me\x1b[0;3H\x1b[2@",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("ab")},
+					{Data: ansi.Text("c"), Style: bold},
+					{Data: ansi.Text("  ")},
+					{Data: ansi.Text("d"), Style: bold},
+					{Data: ansi.Text("e")},
+				},
+			},
+		},
+		{
+			description: "insert characters at the end of the line",
+			input:       "ab\x1b[@",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("ab")}},
+			},
+		},
+		{
+			description: "delete characters",
+			input:       "ab\x1b[1mcd\x1b[mef\x1b[0;1H\x1b[4P",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("af")}},
+			},
+		},
+		{
+			description: "delete characters keeps styles",
+			input:       "ab\x1b[1mcd\x1b[mef\x1b[0;1H\x1b[2P",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("a")},
+					{Data: ansi.Text("d"), Style: bold},
+					{Data: ansi.Text("ef")},
+				},
+			},
+		},
+		{
+			description: "delete characters within a wide character",
+			input:       "a世界b\x1b[0;2H\x1b[2P",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a  b")}},
+			},
+		},
+		{
+			description: "erase characters",
+			input:       "\x1b[1mabcdef\x1b[0;1H\x1b[2X",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("a"), Style: bold},
+					{Data: ansi.Text("  ")},
+					{Data: ansi.Text("def"), Style: bold},
+				},
+			},
+		},
+		{
+			description: "insert lines",
+			input:       "one\ntwo\nthree\x1b[1;2H\x1b[2Lx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("x")}},
+				{},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+			},
+		},
+		{
+			description: "delete lines",
+			input:       "one\ntwo\nthree\nfour\x1b[1;2H\x1b[2Mx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("xour")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_Scrolling(t *testing.T) {
+	const screen = "one\ntwo\nthree\nfour\nfive"
+	for _, tt := range []struct {
+		description string
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "linebreaks scroll at the bottom of the scroll region",
+			input:       screen + "\x1b[1;3r\x1b[3;0Hsix\nseven\nx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("sixr")}},
+				{{Data: ansi.Text("seven")}},
+				{{Data: ansi.Text("x")}},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+		{
+			description: "setting the scroll region moves the cursor home",
+			input:       screen + "\x1b[1;3rx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("xne")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+		{
+			description: "invalid scroll regions are ignored",
+			input:       screen + "\x1b[3;1rx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("four")}},
+				{{Data: ansi.Text("fivex")}},
+			},
+		},
+		{
+			description: "scroll up and down",
+			input:       screen + "\x1b[1;3r\x1b[2S\x1b[T",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+				{},
+				{{Data: ansi.Text("four")}},
+				{},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+		{
+			description: "scrolling the whole screen keeps the lines",
+			input:       screen + "\x1b[2Sx\x1b[0;0Hy",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("yhree")}},
+				{{Data: ansi.Text("four")}},
+				{{Data: ansi.Text("five")}},
+				{},
+				{{Data: ansi.Text("    x")}},
+			},
+		},
+		{
+			description: "lines kept by scroll regions of different sizes are skipped",
+			input:       "L0\nL1\nL2\nL3\x1b[2;3r\x1b[3;0H\n\x1b[r\x1b[S\x1b[0;0HX",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("L0")}},
+				{{Data: ansi.Text("X1")}},
+				{{Data: ansi.Text("L2")}},
+				{{Data: ansi.Text("L3")}},
+			},
+		},
+		{
+			description: "erasing below the cursor leaves the kept lines",
+			input:       "a\nb\x1b[S\x1b[1;0H\x1b[Jx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a")}},
+				{{Data: ansi.Text("b")}},
+				{{Data: ansi.Text("x")}},
+			},
+		},
+		{
+			description: "erasing above the cursor leaves the kept lines",
+			input:       "a\nb\nc\x1b[S\x1b[2;0H\x1b[1Jx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a")}},
+				{},
+				{},
+				{{Data: ansi.Text("x")}},
+			},
+		},
+		{
+			description: "erasing the screen leaves the kept lines",
+			input:       "a\nb\x1b[S\x1b[2J\x1b[0;0Hx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("a")}},
+				{{Data: ansi.Text("x")}},
+			},
+		},
+		{
+			description: "erasing the scrollback removes the kept lines",
+			input:       "a\nb\x1b[2S\x1b[3J\x1b[0;0Hx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("x")}},
+			},
+		},
+		{
+			description: "index and reverse index",
+			input:       screen + "\x1b[1;3r\x1b[3;1H\x1bDa\x1b[1;1H\x1bMb\x1bMc",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("  c")}},
+				{{Data: ansi.Text(" b")}},
+				{{Data: ansi.Text("three")}},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+		{
+			description: "reverse index at the top of the screen",
+			input:       "one\ntwo\x1b[0;0H\x1bMx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("x")}},
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+			},
+		},
+		{
+			description: "inserting and deleting lines within the scroll region",
+			input:       screen + "\x1b[1;3r\x1b[2;0H\x1b[L\x1b[1;0H\x1b[2M",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("three")}},
+				{},
+				{},
+				{{Data: ansi.Text("five")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_ScrollRegionKeepsLines(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines, ansi.WithInitialScreenSize(5, 80))
+
+	// Like apt, keep the last line of the screen for a progress bar
+	input := "$ apt-get install foo\n\n\x1b7\x1b[0;4r\x1b8\x1b[1A"
+	for i := 0; i < 10; i++ {
+		input += fmt.Sprintf("Unpacking pkg%d\n\x1b7\x1b[5;0fProgress: [%3d%%]\x1b8", i, (i+1)*10)
+	}
+	_, err := writer.WriteString(input)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	expected := ansi.Lines{{{Data: ansi.Text("$ apt-get install foo")}}}
+	for i := 0; i < 10; i++ {
+		expected = append(expected, ansi.Line{{Data: ansi.Text(fmt.Sprintf("Unpacking pkg%d", i))}})
+	}
+	g.Expect(lines).To(Equal(append(expected,
+		ansi.Line{},
+		ansi.Line{{Data: ansi.Text("Progress: [100%]")}},
+	)))
+
+	// Reset the scroll region and clear the progress bar
+	_, err = writer.WriteString("\n\x1b7\x1b[0;5r\x1b8\x1b[1A\x1b[J$ ")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(lines).To(Equal(append(expected,
+		ansi.Line{{Data: ansi.Text("$ ")}},
+	)))
+}
+
+func TestWriter_AlternateScreen(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		opts        []ansi.WriterOption
+		input       string
+		lines       ansi.Lines
+		alternate   ansi.Lines
+	}{
+		{
+			description: "alternate screen content is kept separately",
+			input:       "log\nline\x1b[?1049h\x1b[0;0Hfull\nscreen\x1b[?1049l more",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("log")}},
+				{{Data: ansi.Text("line more")}},
+			},
+			alternate: ansi.Lines{
+				{{Data: ansi.Text("full")}},
+				{{Data: ansi.Text("screen")}},
+			},
+		},
+		{
+			description: "entering the alternate screen clears it",
+			input:       "\x1b[?1049hfirst line\x1b[?1049l\x1b[?1049hsecond\x1b[?1049l",
+			lines:       nil,
+			alternate: ansi.Lines{
+				{{Data: ansi.Text("second")}},
+			},
+		},
+		{
+			description: "mode 47 doesn't clear the alternate screen",
+			input:       "\x1b[?47hfirst line\x1b[?47l\x1b[?47hsecond\x1b[?47l",
+			lines:       nil,
+			alternate: ansi.Lines{
+				{{Data: ansi.Text("first linesecond")}},
+			},
+		},
+		{
+			description: "the scroll region is restored",
+			input:       "\x1b[?1049h\x1b[1;2r\x1b[?1049lone\ntwo\nthree",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("one")}},
+				{{Data: ansi.Text("two")}},
+				{{Data: ansi.Text("three")}},
+			},
+		},
+		{
+			description: "mode 47 doesn't restore the cursor",
+			input:       "log\x1b[?47h\x1b[2;1Hx\x1b[?47ly",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("log")}},
+				{},
+				{{Data: ansi.Text("  y")}},
+			},
+			alternate: ansi.Lines{
+				{},
+				{},
+				{{Data: ansi.Text(" x")}},
+			},
+		},
+		{
+			description: "alternate screen content can be discarded",
+			opts:        []ansi.WriterOption{ansi.WithAlternateOutput(nil)},
+			input:       "log\x1b[?1049hfull screen\x1b[?1049l!",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("log!")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines, tt.opts...)
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+			if tt.alternate != nil {
+				g.Expect(writer.AlternateOutput).To(Equal(&tt.alternate))
+			}
+		})
+	}
+}
+
+func TestWriter_Modes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+	g.Expect(writer.AutoWrap).To(BeTrue())
+	g.Expect(writer.CursorVisible).To(BeTrue())
+
+	_, err := writer.WriteString("\x1b[4h\x1b[?25;7l\x1b[?2004h\x1b[?1000l\x1b[20l")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(writer.InsertMode).To(BeTrue())
+	g.Expect(writer.AutoWrap).To(BeFalse())
+	g.Expect(writer.CursorVisible).To(BeFalse())
+	g.Expect(writer.LineDiscipline).To(Equal(ansi.Raw))
+	g.Expect(writer.Modes).To(Equal(map[ansi.Mode]bool{
+		ansi.BracketedPasteMode: true,
+		ansi.PrivateMode | 1000: false,
+	}))
+
+	_, err = writer.WriteString("\x1b[20h")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writer.LineDiscipline).To(Equal(ansi.Cooked))
+}
+
+func TestWriter_InsertMode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+	_, err := writer.WriteString("world\r\x1b[4h\x1b[1mhello \x1b[m\x1b[4lx")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(lines).To(Equal(ansi.Lines{
+		{
+			{Data: ansi.Text("hello "), Style: ansi.Style{Modifier: ansi.Bold}},
+			{Data: ansi.Text("xorld")},
+		},
+	}))
+}
+
+func TestWriter_OriginMode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+	_, err := writer.WriteString("\x1b[2;3r\x1b[?6h")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writer.Position).To(Equal(ansi.Pos{Line: 2}))
+
+	_, err = writer.WriteString("\x1b[2;4H")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writer.Position).To(Equal(ansi.Pos{Line: 3, Col: 4}))
+
+	_, err = writer.WriteString("\x1b[9;1H")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writer.Position).To(Equal(ansi.Pos{Line: 3, Col: 1}))
+
+	_, err = writer.WriteString("\x1b[?6l")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(writer.Position).To(Equal(ansi.Pos{}))
+}
+
+func TestWriter_FixedWidth(t *testing.T) {
+	bold := ansi.Style{Modifier: ansi.Bold}
+	for _, tt := range []struct {
+		description string
+		input       string
+		lines       ansi.Lines
+	}{
+		{
+			description: "text wraps at the width",
+			input:       "hello world",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("hello"), Wrapped: true}},
+				{{Data: ansi.Text(" worl"), Wrapped: true}},
+				{{Data: ansi.Text("d")}},
+			},
+		},
+		{
+			description: "wrapping is deferred until the next character",
+			input:       "hello\r\nworld\x1b[1mx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("hello")}},
+				{{Data: ansi.Text("world"), Wrapped: true}},
+				{{Data: ansi.Text("x"), Style: bold}},
+			},
+		},
+		{
+			description: "the mark is kept on the last chunk",
+			input:       "abcdef\x1b[0;1H\x1b[1mx\x1b[m\x1b[2@",
+			lines: ansi.Lines{
+				{
+					{Data: ansi.Text("a")},
+					{Data: ansi.Text("x"), Style: bold},
+					{Data: ansi.Text("  cde"), Wrapped: true},
+				},
+				{{Data: ansi.Text("f")}},
+			},
+		},
+		{
+			description: "clearing the end of a line removes the mark",
+			input:       "abcdef\x1b[0;3H\x1b[K",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("abcd")}},
+				{{Data: ansi.Text("f")}},
+			},
+		},
+		{
+			description: "the cursor stays within the width",
+			input:       "\x1b[10Cx",
+			lines: ansi.Lines{
+				{{Data: ansi.Text("    x")}},
+			},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var lines ansi.Lines
+			writer := ansi.NewWriter(&lines, ansi.WithInitialScreenSize(10, 5), ansi.WithFixedWidth())
+			_, err := writer.WriteString(tt.input)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(lines).To(Equal(tt.lines))
+		})
+	}
+}
+
+func TestWriter_ReadFrom(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	input := "hello \xe3\x81\x93 \x1b[1mworld\x1b[m\nline 2"
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+
+	// Reading one byte at a time splits up runes and escape sequences
+	n, err := io.Copy(writer, iotest.OneByteReader(strings.NewReader(input)))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(n).To(Equal(int64(len(input))))
+
+	g.Expect(lines).To(Equal(ansi.Lines{
+		{
+			{
+				Data: ansi.Text("hello こ "),
+			},
+			{
+				Data:  ansi.Text("world"),
+				Style: ansi.Style{Modifier: ansi.Bold},
+			},
+		},
+		{
+			{
+				Data: ansi.Text("line 2"),
+			},
+		},
+	}))
+}
+
+func TestWriter_ReadFrom_Error(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+
+	n, err := writer.ReadFrom(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("ab"))))
+	g.Expect(err).To(Equal(iotest.ErrTimeout))
+	g.Expect(n).To(Equal(int64(1)))
+	g.Expect(lines).To(Equal(ansi.Lines{{{Data: ansi.Text("a")}}}))
+}
+
+func TestWriter_WriteString(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var lines ansi.Lines
+	writer := ansi.NewWriter(&lines)
+
+	for _, s := range []string{"hello \xe3", "\x81\x93 \x1b[", "1mworld"} {
+		n, err := writer.WriteString(s)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(n).To(Equal(len(s)))
+	}
+
+	g.Expect(lines).To(Equal(ansi.Lines{
+		{
+			{
+				Data: ansi.Text("hello こ "),
+			},
+			{
+				Data:  ansi.Text("world"),
+				Style: ansi.Style{Modifier: ansi.Bold},
+			},
+		},
+	}))
+}